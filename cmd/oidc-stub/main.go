@@ -0,0 +1,204 @@
+// Command oidc-stub is a local OpenID Connect provider for development and
+// manual testing of the social login. It approves every authorization request
+// for a single configurable user, no real credentials are involved.
+//
+//	go run ./cmd/oidc-stub -addr :9000 -email user@example.com
+//
+// and run the server with OIDC_PROVIDERS=stub, OIDC_STUB_ISSUER=http://localhost:9000,
+// OIDC_STUB_CLIENT_ID=local.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+type authorization struct {
+	clientId      string
+	redirectUri   string
+	codeChallenge string
+	nonce         string
+	expires       time.Time
+}
+
+type stub struct {
+	issuer        string
+	subject       string
+	email         string
+	emailVerified bool
+	name          string
+	key           jwk.Key
+
+	mu    sync.Mutex
+	codes map[string]authorization
+}
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9000", "issuer url, must match OIDC_<NAME>_ISSUER")
+	subject := flag.String("sub", "stub-user-1", "subject of the signed in user")
+	email := flag.String("email", "stub@example.com", "email of the signed in user")
+	emailVerified := flag.Bool("email-verified", true, "whether the email is reported as verified")
+	name := flag.String("name", "Stub User", "name of the signed in user")
+	flag.Parse()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatal(err)
+	}
+	key, err := jwk.FromRaw(rsaKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	_ = key.Set(jwk.KeyIDKey, "stub")
+	_ = key.Set(jwk.AlgorithmKey, jwa.RS256)
+
+	s := &stub{
+		issuer:        *issuer,
+		subject:       *subject,
+		email:         *email,
+		emailVerified: *emailVerified,
+		name:          *name,
+		key:           key,
+		codes:         make(map[string]authorization),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/jwks", s.jwks)
+
+	log.Printf("OIDC stub listening on %s with issuer %s", *addr, *issuer)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+func (s *stub) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.issuer,
+		"authorization_endpoint":                s.issuer + "/authorize",
+		"token_endpoint":                        s.issuer + "/token",
+		"jwks_uri":                              s.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *stub) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "only the code flow with S256 PKCE is supported", http.StatusBadRequest)
+		return
+	}
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || redirect.Scheme == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	s.mu.Lock()
+	s.codes[code] = authorization{
+		clientId:      q.Get("client_id"),
+		redirectUri:   q.Get("redirect_uri"),
+		codeChallenge: q.Get("code_challenge"),
+		nonce:         q.Get("nonce"),
+		expires:       time.Now().Add(time.Minute),
+	}
+	s.mu.Unlock()
+
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *stub) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	s.mu.Lock()
+	auth, ok := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	s.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	if !ok || time.Now().After(auth.expires) ||
+		auth.clientId != r.PostForm.Get("client_id") ||
+		auth.redirectUri != r.PostForm.Get("redirect_uri") ||
+		subtle.ConstantTimeCompare([]byte(challenge), []byte(auth.codeChallenge)) != 1 {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	t := jwt.New()
+	_ = t.Set(jwt.IssuerKey, s.issuer)
+	_ = t.Set(jwt.AudienceKey, auth.clientId)
+	_ = t.Set(jwt.SubjectKey, s.subject)
+	_ = t.Set(jwt.IssuedAtKey, time.Now())
+	_ = t.Set(jwt.ExpirationKey, time.Now().Add(5*time.Minute))
+	_ = t.Set("nonce", auth.nonce)
+	_ = t.Set("email", s.email)
+	_ = t.Set("email_verified", s.emailVerified)
+	_ = t.Set("name", s.name)
+	idToken, err := jwt.Sign(t, jwt.WithKey(jwa.RS256, s.key))
+	if err != nil {
+		writeJson(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     string(idToken),
+	})
+}
+
+func (s *stub) jwks(w http.ResponseWriter, _ *http.Request) {
+	public, err := s.key.PublicKey()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	set := jwk.NewSet()
+	_ = set.AddKey(public)
+	writeJson(w, http.StatusOK, set)
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeJson(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		log.Print(err)
+	}
+}
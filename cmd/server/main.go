@@ -12,6 +12,7 @@ import (
 	"os/signal"
 	"runtime/debug"
 	"syscall"
+	_ "time/tzdata"
 )
 
 func main() {
@@ -46,6 +47,8 @@ func main() {
 	}
 
 	cont := container.New(conf)
+	go cont.AccountService.RunPurge(ctx)
+	go cont.AuthService.RunRefreshTokenPurge(ctx)
 
 	// HTTP Server
 	err = http.Server(
@@ -3,11 +3,32 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const (
+	ProductionEnv    = "production"
+	defaultJwtSecret = "1234567890"
+	defaultTotpKey   = "change-me-totp-encryption-key"
+	minTotpKeyLength = 32
+	// maxPasswordLength matches the cap on passwords in login requests
+	maxPasswordLength = 128
+)
+
+type OidcProvider struct {
+	Name         string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+}
+
 type Configuration struct {
 	//DatabasePath        string
+	Environment         string
 	DatabaseName        string
 	DatabaseHost        string
 	DatabaseUser        string
@@ -16,12 +37,46 @@ type Configuration struct {
 	MigrationLocation   string
 	FileStorageLocation string
 	JwtSecret           string
+	JwtPrivateKey       string
+	JwtVerifyKeys       string
 	JwtTTL              time.Duration
+	RefreshTokenTTL     time.Duration
+	Timezone            string
+	AppUrl              string
+	EmailVerifyTTL      time.Duration
+	PasswordResetTTL    time.Duration
+	PasswordMinLength   int
+	PasswordMaxLength   int
+	PasswordMinClasses  int
+	PasswordCheckCommon bool
+	Argon2Memory        int
+	Argon2Time          int
+	Argon2Threads       int
+	LoginFreeAttempts   int
+	LoginIpFreeAttempts int
+	LoginBaseLockout    time.Duration
+	LoginMaxLockout     time.Duration
+	TotpIssuer          string
+	TotpEncryptionKey   string
+	TwoFactorTTL        time.Duration
+	AccountRestoreTTL   time.Duration
+	PurgeInterval       time.Duration
+	RestrictUnverified  bool
+	TrustProxy          bool
+	MailDriver          string
+	MailFrom            string
+	MailLogFile         string
+	SmtpHost            string
+	SmtpPort            string
+	SmtpUser            string
+	SmtpPassword        string
+	OidcProviders       []OidcProvider
 }
 
 func GetConfiguration() Configuration {
-	return Configuration{
+	conf := Configuration{
 		//DatabasePath:        getOrDefault("DB_PATH", "appname.db"),
+		Environment:         getOrDefault("APP_ENV", "development"),
 		DatabaseName:        getOrFail("DB_NAME"),
 		DatabaseHost:        getOrFail("DB_HOST"),
 		DatabaseUser:        getOrFail("DB_USER"),
@@ -29,9 +84,63 @@ func GetConfiguration() Configuration {
 		MigrateToVersion:    getOrDefault("MIGRATE", "latest"),
 		MigrationLocation:   getOrDefault("MIGRATION_LOCATION", "/app/migrations"),
 		FileStorageLocation: getOrDefault("FILES_LOCATION", "file_storage"),
-		JwtSecret:           getOrDefault("JWT_SECRET", "1234567890"),
-		JwtTTL:              72 * time.Hour,
+		JwtSecret:           getOrDefault("JWT_SECRET", defaultJwtSecret),
+		JwtPrivateKey:       getFromFileOrDefault("JWT_PRIVATE_KEY", ""),
+		JwtVerifyKeys:       getFromFileOrDefault("JWT_VERIFY_KEYS", ""),
+		JwtTTL:              getDurationOrDefault("JWT_TTL", 15*time.Minute),
+		RefreshTokenTTL:     getDurationOrDefault("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		Timezone:            getOrDefault("TIMEZONE", "Europe/Kyiv"),
+		AppUrl:              getOrDefault("APP_URL", "http://localhost:8080"),
+		EmailVerifyTTL:      getDurationOrDefault("EMAIL_VERIFY_TTL", 48*time.Hour),
+		PasswordResetTTL:    getDurationOrDefault("PASSWORD_RESET_TTL", time.Hour),
+		PasswordMinLength:   getIntOrDefault("PASSWORD_MIN_LENGTH", 8),
+		PasswordMaxLength:   getIntOrDefault("PASSWORD_MAX_LENGTH", 128),
+		PasswordMinClasses:  getIntOrDefault("PASSWORD_MIN_CLASSES", 1),
+		PasswordCheckCommon: getBoolOrDefault("PASSWORD_CHECK_COMMON", true),
+		Argon2Memory:        getIntOrDefault("ARGON2_MEMORY", 19*1024),
+		Argon2Time:          getIntOrDefault("ARGON2_TIME", 2),
+		Argon2Threads:       getIntOrDefault("ARGON2_THREADS", 1),
+		LoginFreeAttempts:   getIntOrDefault("LOGIN_FREE_ATTEMPTS", 5),
+		LoginIpFreeAttempts: getIntOrDefault("LOGIN_IP_FREE_ATTEMPTS", 20),
+		LoginBaseLockout:    getDurationOrDefault("LOGIN_BASE_LOCKOUT", 30*time.Second),
+		LoginMaxLockout:     getDurationOrDefault("LOGIN_MAX_LOCKOUT", 15*time.Minute),
+		TotpIssuer:          getOrDefault("TOTP_ISSUER", "Zahyst"),
+		TotpEncryptionKey:   getOrDefault("TOTP_ENCRYPTION_KEY", defaultTotpKey),
+		TwoFactorTTL:        getDurationOrDefault("TWO_FACTOR_CHALLENGE_TTL", 5*time.Minute),
+		AccountRestoreTTL:   getDurationOrDefault("ACCOUNT_RESTORE_TTL", 30*24*time.Hour),
+		PurgeInterval:       getDurationOrDefault("PURGE_INTERVAL", time.Hour),
+		RestrictUnverified:  getBoolOrDefault("RESTRICT_UNVERIFIED", true),
+		TrustProxy:          getBoolOrDefault("TRUST_PROXY", false),
+		MailDriver:          getOrDefault("MAIL_DRIVER", "log"),
+		MailFrom:            getOrDefault("MAIL_FROM", "no-reply@localhost"),
+		MailLogFile:         getOrDefault("MAIL_LOG_FILE", ""),
+		SmtpHost:            getOrDefault("SMTP_HOST", "localhost"),
+		SmtpPort:            getOrDefault("SMTP_PORT", "25"),
+		SmtpUser:            getOrDefault("SMTP_USER", ""),
+		SmtpPassword:        getOrDefault("SMTP_PASSWORD", ""),
+	}
+	conf.OidcProviders = getOidcProviders(conf.AppUrl)
+
+	// the secret also signs email and login challenge tokens, so it has to be changed even with asymmetric keys
+	if conf.IsProduction() && conf.JwtSecret == defaultJwtSecret {
+		log.Fatal("JWT_SECRET env var must be changed from the default value in production")
 	}
+	if conf.PurgeInterval <= 0 {
+		log.Fatal("PURGE_INTERVAL env var must be a positive duration")
+	}
+	if conf.PasswordMaxLength > maxPasswordLength {
+		log.Fatalf("PASSWORD_MAX_LENGTH env var can't be over %d", maxPasswordLength)
+	}
+	// the key protects every TOTP secret at rest, a leaked or guessable one defeats the second factor
+	if conf.IsProduction() && (conf.TotpEncryptionKey == defaultTotpKey || len(conf.TotpEncryptionKey) < minTotpKeyLength) {
+		log.Fatalf("TOTP_ENCRYPTION_KEY env var must be changed from the default value to at least %d characters in production", minTotpKeyLength)
+	}
+
+	return conf
+}
+
+func (c Configuration) IsProduction() bool {
+	return c.Environment == ProductionEnv
 }
 
 //nolint:unused
@@ -50,3 +159,77 @@ func getOrDefault(key, defaultVal string) string {
 	}
 	return env
 }
+
+func getDurationOrDefault(key string, defaultVal time.Duration) time.Duration {
+	env, set := os.LookupEnv(key)
+	if !set {
+		return defaultVal
+	}
+	duration, err := time.ParseDuration(env)
+	if err != nil {
+		log.Fatalf("%s env var is not a valid duration: %s", key, err)
+	}
+	return duration
+}
+
+func getBoolOrDefault(key string, defaultVal bool) bool {
+	env, set := os.LookupEnv(key)
+	if !set {
+		return defaultVal
+	}
+	val, err := strconv.ParseBool(env)
+	if err != nil {
+		log.Fatalf("%s env var is not a valid bool: %s", key, err)
+	}
+	return val
+}
+
+func getIntOrDefault(key string, defaultVal int) int {
+	env, set := os.LookupEnv(key)
+	if !set {
+		return defaultVal
+	}
+	val, err := strconv.Atoi(env)
+	if err != nil {
+		log.Fatalf("%s env var is not a valid int: %s", key, err)
+	}
+	return val
+}
+
+// getFromFileOrDefault reads the value from the env var or from the file named by the KEY_FILE env var.
+func getFromFileOrDefault(key, defaultVal string) string {
+	if env, set := os.LookupEnv(key); set {
+		return env
+	}
+	path, set := os.LookupEnv(key + "_FILE")
+	if !set {
+		return defaultVal
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("unable to read %s_FILE: %s", key, err)
+	}
+	return string(content)
+}
+
+// getOidcProviders reads the providers listed in OIDC_PROVIDERS, e.g. "google,apple",
+// each configured with OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL and _SCOPES.
+func getOidcProviders(appUrl string) []OidcProvider {
+	var providers []OidcProvider
+	for _, name := range strings.Split(getOrDefault("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		providers = append(providers, OidcProvider{
+			Name:         name,
+			Issuer:       getOrFail(prefix + "ISSUER"),
+			ClientId:     getOrFail(prefix + "CLIENT_ID"),
+			ClientSecret: getOrDefault(prefix+"CLIENT_SECRET", ""),
+			RedirectUrl:  getOrDefault(prefix+"REDIRECT_URL", strings.TrimSuffix(appUrl, "/")+"/api/v1/auth/oidc/"+name+"/callback"),
+			Scopes:       strings.Fields(getOrDefault(prefix+"SCOPES", "openid email profile")),
+		})
+	}
+	return providers
+}
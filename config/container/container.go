@@ -4,16 +4,23 @@ import (
 	"boilerplate/config"
 	"boilerplate/internal/app"
 	"boilerplate/internal/infra/database"
+	"boilerplate/internal/infra/encryption"
+	"boilerplate/internal/infra/filesystem"
 	"boilerplate/internal/infra/http/controllers"
 	"boilerplate/internal/infra/http/middlewares"
+	"boilerplate/internal/infra/jwtkeys"
+	"boilerplate/internal/infra/mail"
+	"boilerplate/internal/infra/oidc"
+	"boilerplate/internal/infra/password"
+	"boilerplate/internal/infra/ratelimit"
 
-	"github.com/go-chi/jwtauth/v5"
 	"github.com/upper/db/v4"
 	"github.com/upper/db/v4/adapter/postgresql"
 
 	//"github.com/upper/db/v4/adapter/sqlite"
 	"log"
 	"net/http"
+	"time"
 )
 
 type Container struct {
@@ -23,66 +30,172 @@ type Container struct {
 }
 
 type Middlewares struct {
-	AuthMw func(http.Handler) http.Handler
+	AuthMw     func(http.Handler) http.Handler
+	VerifiedMw func(http.Handler) http.Handler
+	RealIpMw   func(http.Handler) http.Handler
 }
 
 type Services struct {
 	app.AuthService
 	app.UserService
+	app.AccountService
 	app.LocationService
+	app.LocationTypeService
+	app.LocationPhotoService
 	app.GroupService
 	app.GroupMemberService
+	app.GroupInviteService
+	app.GroupJoinRequestService
+	app.GroupTransferService
+	app.GroupRoleService
 }
 
 type Controllers struct {
 	controllers.AuthController
+	controllers.TwoFactorController
+	controllers.OidcController
 	controllers.UserController
 	controllers.LocationController
+	controllers.LocationTypeController
 	controllers.GroupController
 	controllers.GroupMemberController
+	controllers.GroupInviteController
+	controllers.GroupJoinRequestController
+	controllers.GroupTransferController
+	controllers.GroupRoleController
 }
 
 func New(conf config.Configuration) Container {
-	tknAuth := jwtauth.New("HS256", []byte(conf.JwtSecret), nil)
+	tknKeys := getJwtKeys(conf)
+	timezone, err := time.LoadLocation(conf.Timezone)
+	if err != nil {
+		log.Fatalf("Unable to load timezone %s: %q\n", conf.Timezone, err)
+	}
 	sess := getDbSess(conf)
 
 	userRepository := database.NewUserRepository(sess)
 	sessionRepository := database.NewSessRepository(sess)
+	refreshTokenRepository := database.NewRefreshTokenRepository(sess)
+	passwordResetTokenRepository := database.NewPasswordResetTokenRepository(sess)
+	twoFactorRepository := database.NewTwoFactorRepository(sess)
+	userIdentityRepository := database.NewUserIdentityRepository(sess)
 	locationRepository := database.NewLocationRepository(sess)
+	locationTypeRepository := database.NewLocationTypeRepository(sess)
+	occupancyEventRepository := database.NewOccupancyEventRepository(sess)
+	locationPhotoRepository := database.NewLocationPhotoRepository(sess)
+	locationRevisionRepository := database.NewLocationRevisionRepository(sess)
 	groupRepository := database.NewGroupRepository(sess)
 	groupMemberRepository := database.NewGroupMemberRepository(sess)
+	groupInviteRepository := database.NewGroupInviteRepository(sess)
+	groupJoinRequestRepository := database.NewGroupJoinRequestRepository(sess)
+	groupTransferRepository := database.NewGroupTransferRepository(sess)
+	groupRoleRepository := database.NewGroupRoleRepository(sess)
+	accountRepository := database.NewAccountRepository(sess)
 
-	userService := app.NewUserService(userRepository)
-	authService := app.NewAuthService(sessionRepository, userService, conf, tknAuth)
-	locationService := app.NewLocationService(locationRepository)
-	groupService := app.NewGroupService(groupRepository)
-	groupMemberService := app.NewGroupMemberService(groupMemberRepository, groupRepository, userRepository)
+	passwordPolicy := password.Policy{
+		MinLength:    conf.PasswordMinLength,
+		MaxLength:    conf.PasswordMaxLength,
+		MinClasses:   conf.PasswordMinClasses,
+		RejectCommon: conf.PasswordCheckCommon,
+	}
+	userService := app.NewUserService(userRepository, getPasswordHasher(conf), passwordPolicy)
+	mailer := getMailer(conf)
+	emailVerificationService := app.NewEmailVerificationService(userService, mailer, conf)
+	totpCipher, err := encryption.NewAesCipher(conf.TotpEncryptionKey)
+	if err != nil {
+		log.Fatalf("Unable to create TOTP cipher: %q\n", err)
+	}
+	twoFactorService := app.NewTwoFactorService(twoFactorRepository, sessionRepository, totpCipher, conf)
+	accountLimiter := ratelimit.NewMemoryLimiter(ratelimit.Policy{
+		FreeAttempts: conf.LoginFreeAttempts,
+		BaseDelay:    conf.LoginBaseLockout,
+		MaxDelay:     conf.LoginMaxLockout,
+		ForgetAfter:  24 * time.Hour,
+	})
+	ipLimiter := ratelimit.NewMemoryLimiter(ratelimit.Policy{
+		FreeAttempts: conf.LoginIpFreeAttempts,
+		BaseDelay:    conf.LoginBaseLockout,
+		MaxDelay:     conf.LoginMaxLockout,
+		ForgetAfter:  24 * time.Hour,
+	})
+	// reset requests are counted whether the email exists or not, the free attempts allow a few retries
+	resetEmailLimiter := ratelimit.NewMemoryLimiter(ratelimit.Policy{
+		FreeAttempts: 3,
+		BaseDelay:    time.Minute,
+		MaxDelay:     time.Hour,
+		ForgetAfter:  24 * time.Hour,
+	})
+	resetIpLimiter := ratelimit.NewMemoryLimiter(ratelimit.Policy{
+		FreeAttempts: conf.LoginIpFreeAttempts,
+		BaseDelay:    time.Minute,
+		MaxDelay:     time.Hour,
+		ForgetAfter:  24 * time.Hour,
+	})
+	passwordResetService := app.NewPasswordResetService(passwordResetTokenRepository, userService, mailer, resetEmailLimiter, resetIpLimiter, conf)
+	authService := app.NewAuthService(sessionRepository, refreshTokenRepository, userService, emailVerificationService, twoFactorService, accountLimiter, ipLimiter, conf, tknKeys)
+	oidcService := app.NewOidcService(getOidcProviders(conf), userIdentityRepository, userService, authService)
+	fileStorageService := filesystem.NewFileStorageService(conf.FileStorageLocation)
+	locationTypeService := app.NewLocationTypeService(locationTypeRepository)
+	locationPhotoService := app.NewLocationPhotoService(locationPhotoRepository, fileStorageService)
+	groupService := app.NewGroupService(groupRepository, groupMemberRepository)
+	groupMemberService := app.NewGroupMemberService(groupMemberRepository, groupRepository, groupRoleRepository, userRepository)
+	groupInviteService := app.NewGroupInviteService(groupInviteRepository)
+	groupJoinRequestService := app.NewGroupJoinRequestService(groupJoinRequestRepository)
+	groupTransferService := app.NewGroupTransferService(groupTransferRepository)
+	groupRoleService := app.NewGroupRoleService(groupRoleRepository)
+	locationService := app.NewLocationService(locationRepository, occupancyEventRepository, locationRevisionRepository, locationTypeService, locationPhotoService, groupService, groupMemberService, timezone)
+	accountService := app.NewAccountService(accountRepository, userIdentityRepository, groupRepository, groupMemberRepository, occupancyEventRepository, userService, authService, locationService, locationPhotoService, fileStorageService, conf)
 
-	authController := controllers.NewAuthController(authService, userService)
-	userController := controllers.NewUserController(userService)
-	locationController := controllers.NewLocationController(locationService)
+	authController := controllers.NewAuthController(authService, userService, emailVerificationService, passwordResetService)
+	twoFactorController := controllers.NewTwoFactorController(twoFactorService)
+	oidcController := controllers.NewOidcController(oidcService)
+	userController := controllers.NewUserController(userService, accountService)
+	locationController := controllers.NewLocationController(locationService, locationPhotoService)
+	locationTypeController := controllers.NewLocationTypeController(locationTypeService)
 	groupController := controllers.NewGroupController(groupService)
 	groupMemberController := controllers.NewGroupMemberController(groupMemberService)
+	groupInviteController := controllers.NewGroupInviteController(groupInviteService)
+	groupJoinRequestController := controllers.NewGroupJoinRequestController(groupJoinRequestService)
+	groupTransferController := controllers.NewGroupTransferController(groupTransferService)
+	groupRoleController := controllers.NewGroupRoleController(groupRoleService)
 
-	authMiddleware := middlewares.AuthMiddleware(tknAuth, authService, userService)
+	authMiddleware := middlewares.AuthMiddleware(tknKeys, authService, userService)
+	verifiedMiddleware := middlewares.VerifiedMiddleware(conf.RestrictUnverified)
+	realIpMiddleware := middlewares.RealIpMiddleware(conf.TrustProxy)
 
 	return Container{
 		Middlewares: Middlewares{
-			AuthMw: authMiddleware,
+			AuthMw:     authMiddleware,
+			VerifiedMw: verifiedMiddleware,
+			RealIpMw:   realIpMiddleware,
 		},
 		Services: Services{
 			authService,
 			userService,
+			accountService,
 			locationService,
+			locationTypeService,
+			locationPhotoService,
 			groupService,
 			groupMemberService,
+			groupInviteService,
+			groupJoinRequestService,
+			groupTransferService,
+			groupRoleService,
 		},
 		Controllers: Controllers{
 			authController,
+			twoFactorController,
+			oidcController,
 			userController,
 			locationController,
+			locationTypeController,
 			groupController,
 			groupMemberController,
+			groupInviteController,
+			groupJoinRequestController,
+			groupTransferController,
+			groupRoleController,
 		},
 	}
 }
@@ -104,3 +217,58 @@ func getDbSess(conf config.Configuration) db.Session {
 	}
 	return sess
 }
+
+func getMailer(conf config.Configuration) mail.Mailer {
+	switch conf.MailDriver {
+	case "smtp":
+		return mail.NewSmtpMailer(conf.SmtpHost, conf.SmtpPort, conf.SmtpUser, conf.SmtpPassword, conf.MailFrom)
+	case "log", "file":
+		return mail.NewFileMailer(conf.MailLogFile)
+	default:
+		log.Fatalf("Unknown mail driver %s", conf.MailDriver)
+		return nil
+	}
+}
+
+func getJwtKeys(conf config.Configuration) jwtkeys.Keys {
+	var (
+		keys jwtkeys.Keys
+		err  error
+	)
+	if conf.JwtPrivateKey != "" {
+		keys, err = jwtkeys.NewAsymmetricKeys([]byte(conf.JwtPrivateKey), []byte(conf.JwtVerifyKeys))
+	} else {
+		keys, err = jwtkeys.NewHmacKeys([]byte(conf.JwtSecret))
+	}
+	if err != nil {
+		log.Fatalf("Unable to load JWT keys: %q\n", err)
+	}
+	return keys
+}
+
+func getPasswordHasher(conf config.Configuration) password.Hasher {
+	if conf.Argon2Memory < 8*conf.Argon2Threads || conf.Argon2Time < 1 || conf.Argon2Threads < 1 || conf.Argon2Threads > 255 {
+		log.Fatalf("Invalid argon2 parameters: memory %d KiB, time %d, threads %d\n", conf.Argon2Memory, conf.Argon2Time, conf.Argon2Threads)
+	}
+	return password.NewArgon2idHasher(password.Argon2Params{
+		Memory:  uint32(conf.Argon2Memory),
+		Time:    uint32(conf.Argon2Time),
+		Threads: uint8(conf.Argon2Threads),
+	})
+}
+
+func getOidcProviders(conf config.Configuration) []oidc.Provider {
+	client := oidc.NewHttpClient()
+	providers := make([]oidc.Provider, len(conf.OidcProviders))
+	for i, p := range conf.OidcProviders {
+		providers[i] = oidc.NewProvider(oidc.ProviderConfig{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientId:     p.ClientId,
+			ClientSecret: p.ClientSecret,
+			RedirectUrl:  p.RedirectUrl,
+			Scopes:       p.Scopes,
+		}, client)
+	}
+	return providers
+}
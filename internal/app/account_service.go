@@ -0,0 +1,182 @@
+package app
+
+import (
+	"boilerplate/config"
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/database"
+	"boilerplate/internal/infra/filesystem"
+	"context"
+	"log"
+	"time"
+)
+
+type AccountService interface {
+	Delete(user domain.User) error
+	Export(user domain.User) (domain.AccountExport, error)
+	PurgeExpired() (int, error)
+	RunPurge(ctx context.Context)
+}
+
+type accountService struct {
+	accountRepo        database.AccountRepository
+	userIdentityRepo   database.UserIdentityRepository
+	groupRepo          database.GroupRepository
+	groupMemberRepo    database.GroupMemberRepository
+	occupancyEventRepo database.OccupancyEventRepository
+	userService        UserService
+	authService        AuthService
+	locationService    LocationService
+	photoService       LocationPhotoService
+	fileStorageService filesystem.FileStorageService
+	config             config.Configuration
+}
+
+func NewAccountService(
+	ar database.AccountRepository,
+	uir database.UserIdentityRepository,
+	gr database.GroupRepository,
+	gmr database.GroupMemberRepository,
+	oer database.OccupancyEventRepository,
+	us UserService,
+	as AuthService,
+	ls LocationService,
+	lps LocationPhotoService,
+	fss filesystem.FileStorageService,
+	cf config.Configuration,
+) AccountService {
+	return accountService{
+		accountRepo:        ar,
+		userIdentityRepo:   uir,
+		groupRepo:          gr,
+		groupMemberRepo:    gmr,
+		occupancyEventRepo: oer,
+		userService:        us,
+		authService:        as,
+		locationService:    ls,
+		photoService:       lps,
+		fileStorageService: fss,
+		config:             cf,
+	}
+}
+
+// Delete marks the account as deleted and signs it out everywhere. The data is kept
+// until the restore period is over and the account is purged.
+func (s accountService) Delete(user domain.User) error {
+	err := s.userService.Delete(user.Id)
+	if err != nil {
+		log.Printf("AccountService: %s", err)
+		return err
+	}
+
+	err = s.authService.LogoutAll(user.Id)
+	if err != nil {
+		log.Printf("AccountService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s accountService) Export(user domain.User) (domain.AccountExport, error) {
+	export := domain.AccountExport{User: user, GeneratedDate: time.Now()}
+
+	var err error
+	export.Sessions, err = s.authService.ListSessions(user.Id)
+	if err != nil {
+		log.Printf("AccountService: %s", err)
+		return domain.AccountExport{}, err
+	}
+
+	export.Identities, err = s.userIdentityRepo.FindByUserId(user.Id)
+	if err != nil {
+		log.Printf("AccountService: %s", err)
+		return domain.AccountExport{}, err
+	}
+
+	err = s.locationService.ExportByUserId(user.Id, domain.LocationFilter{}, func(location domain.Location) error {
+		export.Locations = append(export.Locations, location)
+		return nil
+	})
+	if err != nil {
+		log.Printf("AccountService: %s", err)
+		return domain.AccountExport{}, err
+	}
+	for i, location := range export.Locations {
+		export.Locations[i].Photos, err = s.photoService.FindByLocationId(location.Id)
+		if err != nil {
+			log.Printf("AccountService: %s", err)
+			return domain.AccountExport{}, err
+		}
+	}
+
+	groups, err := s.groupRepo.FindByUserId(user.Id)
+	if err != nil {
+		log.Printf("AccountService: %s", err)
+		return domain.AccountExport{}, err
+	}
+	export.Groups = groups.Items
+
+	memberships, err := s.groupMemberRepo.FindByUserId(user.Id)
+	if err != nil {
+		log.Printf("AccountService: %s", err)
+		return domain.AccountExport{}, err
+	}
+	export.Memberships = memberships.Items
+
+	events, err := s.occupancyEventRepo.FindByUserId(user.Id)
+	if err != nil {
+		log.Printf("AccountService: %s", err)
+		return domain.AccountExport{}, err
+	}
+	export.OccupancyEvents = events.Items
+
+	return export, nil
+}
+
+// PurgeExpired permanently removes the accounts deleted longer than the restore period ago.
+// An account that fails to purge is skipped until the next run. Returns the number of purged accounts.
+func (s accountService) PurgeExpired() (int, error) {
+	ids, err := s.accountRepo.FindPurgeable(time.Now().Add(-s.config.AccountRestoreTTL))
+	if err != nil {
+		log.Printf("AccountService: %s", err)
+		return 0, err
+	}
+
+	count := 0
+	for _, id := range ids {
+		paths, err := s.accountRepo.Purge(id)
+		if err != nil {
+			log.Printf("AccountService: failed to purge user %d %s", id, err)
+			continue
+		}
+		count++
+
+		for _, path := range paths {
+			err = s.fileStorageService.RemoveFile(path)
+			if err != nil {
+				log.Printf("AccountService: %s", err)
+			}
+		}
+	}
+
+	return count, nil
+}
+
+// RunPurge purges expired accounts every PurgeInterval until the context is done.
+func (s accountService) RunPurge(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		count, err := s.PurgeExpired()
+		if err == nil && count > 0 {
+			log.Printf("AccountService: purged %d deleted accounts", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
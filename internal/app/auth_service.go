@@ -4,94 +4,387 @@ import (
 	"boilerplate/config"
 	"boilerplate/internal/domain"
 	"boilerplate/internal/infra/database"
+	"boilerplate/internal/infra/jwtkeys"
+	"boilerplate/internal/infra/ratelimit"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwk"
 	"github.com/upper/db/v4"
-	"golang.org/x/crypto/bcrypt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// sessionTouchInterval limits how often the last seen date of a session is written.
+const sessionTouchInterval = 5 * time.Minute
+
+// purposes of the challenge tokens issued for the second step of two-factor authentication
+const (
+	loginChallenge   = "login-challenge"
+	restoreChallenge = "restore-challenge"
+)
+
+var (
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused, session revoked")
+	ErrInvalidChallenge    = errors.New("invalid or expired login challenge")
+	ErrRestoreExpired      = errors.New("account restore period is over")
+)
+
+// TooManyAttemptsError is returned by Login while the account or the client address is locked out.
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
 type AuthService interface {
-	Register(user domain.User) (domain.User, string, error)
-	Login(user domain.User) (domain.User, string, error)
+	Register(user domain.User, client domain.Session) (domain.User, domain.AuthTokens, error)
+	Login(user domain.User, client domain.Session) (domain.User, domain.AuthTokens, error)
+	Restore(user domain.User, client domain.Session) (domain.User, domain.AuthTokens, error)
 	ChangePassword(user domain.User, req domain.ChangePassword, sess domain.Session) error
+	CompleteLogin(user domain.User, client domain.Session) (domain.AuthTokens, error)
+	VerifyTwoFactor(req domain.TwoFactorLogin, client domain.Session) (domain.User, domain.AuthTokens, error)
+	Refresh(refreshToken string) (domain.User, domain.AuthTokens, error)
 	Logout(sess domain.Session) error
-	Check(sess domain.Session) error
-	GenerateJwt(user domain.User) (string, error)
+	LogoutAll(userId uint64) error
+	Check(sess domain.Session) (domain.Session, error)
+	PublicKeys() jwk.Set
+	GenerateJwt(user domain.User, client domain.Session) (domain.AuthTokens, error)
+	ListSessions(userId uint64) ([]domain.Session, error)
+	RevokeSession(sess domain.Session) error
+	RevokeOtherSessions(sess domain.Session) error
+	RunRefreshTokenPurge(ctx context.Context)
 }
 
 type authService struct {
-	authRepo    database.SessionRepository
-	userService UserService
-	config      config.Configuration
-	tokenAuth   *jwtauth.JWTAuth
+	authRepo                 database.SessionRepository
+	refreshTokenRepo         database.RefreshTokenRepository
+	userService              UserService
+	emailVerificationService EmailVerificationService
+	twoFactorService         TwoFactorService
+	accountLimiter           ratelimit.Limiter
+	ipLimiter                ratelimit.Limiter
+	config                   config.Configuration
+	tokenKeys                jwtkeys.Keys
 }
 
-func NewAuthService(ar database.SessionRepository, us UserService, cf config.Configuration, ta *jwtauth.JWTAuth) AuthService {
+func NewAuthService(ar database.SessionRepository, rtr database.RefreshTokenRepository, us UserService, evs EmailVerificationService, tfs TwoFactorService, al ratelimit.Limiter, il ratelimit.Limiter, cf config.Configuration, tk jwtkeys.Keys) AuthService {
 	return authService{
-		authRepo:    ar,
-		userService: us,
-		config:      cf,
-		tokenAuth:   ta,
+		authRepo:                 ar,
+		refreshTokenRepo:         rtr,
+		userService:              us,
+		emailVerificationService: evs,
+		twoFactorService:         tfs,
+		accountLimiter:           al,
+		ipLimiter:                il,
+		config:                   cf,
+		tokenKeys:                tk,
 	}
 }
 
-func (s authService) Register(user domain.User) (domain.User, string, error) {
-	_, err := s.userService.FindByEmail(user.Email)
+func (s authService) Register(user domain.User, client domain.Session) (domain.User, domain.AuthTokens, error) {
+	err := s.userService.ValidatePassword(user.Password)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	_, err = s.userService.FindByEmail(user.Email)
 	if err == nil {
 		log.Printf("invalid credentials")
-		return domain.User{}, "", errors.New("invalid credentials")
+		return domain.User{}, domain.AuthTokens{}, errors.New("invalid credentials")
 	} else if !errors.Is(err, db.ErrNoMoreRows) {
 		log.Print(err)
-		return domain.User{}, "", err
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	// the email stays taken by a deleted account until it is purged, so it can still be restored
+	_, err = s.userService.FindDeletedByEmail(user.Email)
+	if err == nil {
+		log.Printf("invalid credentials")
+		return domain.User{}, domain.AuthTokens{}, errors.New("invalid credentials")
+	} else if !errors.Is(err, db.ErrNoMoreRows) {
+		log.Print(err)
+		return domain.User{}, domain.AuthTokens{}, err
 	}
 
 	user, err = s.userService.Save(user)
 	if err != nil {
 		log.Print(err)
-		return domain.User{}, "", err
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	// the user can request another email, so a delivery failure should not block registration
+	err = s.emailVerificationService.SendVerification(user)
+	if err != nil {
+		log.Printf("AuthService: failed to send verification email %s", err)
+	}
+
+	tokens, err := s.GenerateJwt(user, client)
+	return user, tokens, err
+}
+
+func (s authService) Login(user domain.User, client domain.Session) (domain.User, domain.AuthTokens, error) {
+	u, err := s.authenticate(user, client, s.userService.FindByEmail)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, err
 	}
 
-	token, err := s.GenerateJwt(user)
-	return user, token, err
+	tokens, err := s.CompleteLogin(u, client)
+	return u, tokens, err
 }
 
-func (s authService) Login(user domain.User) (domain.User, string, error) {
-	u, err := s.userService.FindByEmail(user.Email)
+// Restore brings back an account deleted within the restore period and logs into it.
+// With two-factor authentication enabled the account is restored only after the second step.
+func (s authService) Restore(user domain.User, client domain.Session) (domain.User, domain.AuthTokens, error) {
+	u, err := s.authenticate(user, client, s.userService.FindDeletedByEmail)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+	if u.DeletedDate.Before(s.restoreDeadline()) {
+		return domain.User{}, domain.AuthTokens{}, ErrRestoreExpired
+	}
+
+	twoFactor, err := s.twoFactorService.IsEnabled(u.Id)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+	if twoFactor {
+		return u, domain.AuthTokens{ChallengeToken: s.challengeToken(restoreChallenge, u.Id)}, nil
+	}
+
+	err = s.restore(u.Id)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+	u.DeletedDate = nil
+
+	tokens, err := s.GenerateJwt(u, client)
+	return u, tokens, err
+}
+
+// restore un-deletes the account, refusing ones deleted before the restore period.
+func (s authService) restore(userId uint64) error {
+	err := s.userService.Restore(userId, s.restoreDeadline())
+	if errors.Is(err, db.ErrNoMoreRows) {
+		return ErrRestoreExpired
+	}
+	return err
+}
+
+// restoreDeadline is the earliest deletion time an account can still be restored from.
+func (s authService) restoreDeadline() time.Time {
+	return time.Now().Add(-s.config.AccountRestoreTTL)
+}
+
+// authenticate checks the password of the user found by email, counting failures against
+// both the account and the client address.
+func (s authService) authenticate(user domain.User, client domain.Session, find func(email string) (domain.User, error)) (domain.User, error) {
+	accountKey := strings.ToLower(user.Email)
+	retryAfter := max(s.accountLimiter.Check(accountKey), s.ipLimiter.Check(client.Ip))
+	if retryAfter > 0 {
+		return domain.User{}, TooManyAttemptsError{RetryAfter: retryAfter}
+	}
+
+	u, err := find(user.Email)
+	if err != nil && !errors.Is(err, db.ErrNoMoreRows) {
+		log.Printf("AuthService: login error %s", err)
+		return domain.User{}, err
+	}
+
+	// the zero user of an unknown email is checked too, so the timing doesn't reveal it
+	if !s.userService.CheckPassword(u, user.Password) {
+		log.Printf("AuthService: failed login attempt from %s", client.Ip)
+		retryAfter = max(s.accountLimiter.Fail(accountKey), s.ipLimiter.Fail(client.Ip))
+		if retryAfter > 0 {
+			return domain.User{}, TooManyAttemptsError{RetryAfter: retryAfter}
+		}
+		return domain.User{}, ErrInvalidCredentials
+	}
+	// the address is not reset, a valid account of an attacker should not lift its lockout
+	s.accountLimiter.Reset(accountKey)
+	s.userService.UpgradePasswordHash(u, user.Password)
+
+	return u, nil
+}
+
+// CompleteLogin issues tokens for an authenticated user, or a challenge token
+// when the user has two-factor authentication enabled.
+func (s authService) CompleteLogin(user domain.User, client domain.Session) (domain.AuthTokens, error) {
+	twoFactor, err := s.twoFactorService.IsEnabled(user.Id)
+	if err != nil {
+		return domain.AuthTokens{}, err
+	}
+	if twoFactor {
+		return domain.AuthTokens{ChallengeToken: s.challengeToken(loginChallenge, user.Id)}, nil
+	}
+
+	return s.GenerateJwt(user, client)
+}
+
+func (s authService) challengeToken(purpose string, userId uint64) string {
+	return signToken(s.config.JwtSecret, purpose, time.Now().Add(s.config.TwoFactorTTL), strconv.FormatUint(userId, 10))
+}
+
+// VerifyTwoFactor completes the login or the account restore started with a challenge token.
+func (s authService) VerifyTwoFactor(req domain.TwoFactorLogin, client domain.Session) (domain.User, domain.AuthTokens, error) {
+	restore := false
+	fields, err := parseSignedToken(s.config.JwtSecret, loginChallenge, req.ChallengeToken, 1)
+	if err != nil {
+		restore = true
+		fields, err = parseSignedToken(s.config.JwtSecret, restoreChallenge, req.ChallengeToken, 1)
+	}
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, ErrInvalidChallenge
+	}
+	userId, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, ErrInvalidChallenge
+	}
+
+	limiterKey := "2fa:" + fields[0]
+	retryAfter := s.accountLimiter.Check(limiterKey)
+	if retryAfter > 0 {
+		return domain.User{}, domain.AuthTokens{}, TooManyAttemptsError{RetryAfter: retryAfter}
+	}
+
+	err = s.twoFactorService.Verify(userId, req.Code)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidTwoFactorCode) {
+			return domain.User{}, domain.AuthTokens{}, err
+		}
+		log.Printf("AuthService: failed two-factor attempt from %s", client.Ip)
+		retryAfter = s.accountLimiter.Fail(limiterKey)
+		if retryAfter > 0 {
+			return domain.User{}, domain.AuthTokens{}, TooManyAttemptsError{RetryAfter: retryAfter}
+		}
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+	s.accountLimiter.Reset(limiterKey)
+
+	// the account may have passed the restore period since the challenge was issued
+	if restore {
+		err = s.restore(userId)
+		if err != nil {
+			return domain.User{}, domain.AuthTokens{}, err
+		}
+	}
+
+	user, err := s.userService.FindById(userId)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, ErrInvalidChallenge
+	}
+
+	tokens, err := s.GenerateJwt(user, client)
+	return user, tokens, err
+}
+
+// Refresh exchanges a refresh token for a new pair of tokens within the same session.
+// Every refresh token can be used only once, presenting a used one revokes the whole session.
+func (s authService) Refresh(refreshToken string) (domain.User, domain.AuthTokens, error) {
+	token, err := s.refreshTokenRepo.FindByHash(hashToken(refreshToken))
 	if err != nil {
 		if errors.Is(err, db.ErrNoMoreRows) {
-			log.Printf("AuthService: failed to find user %s", err)
+			return domain.User{}, domain.AuthTokens{}, ErrInvalidRefreshToken
 		}
-		log.Printf("AuthService: login error %s", err)
-		return domain.User{}, "", err
+		log.Printf("AuthService: failed to find refresh token %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
 	}
 
-	valid := s.checkPasswordHash(user.Password, u.Password)
-	if !valid {
-		return domain.User{}, "", errors.New("invalid credentials")
+	sess := domain.Session{UserId: token.UserId, UUID: token.SessionUUID}
+	if token.UsedDate != nil {
+		return domain.User{}, domain.AuthTokens{}, s.revokeReused(sess)
+	}
+	if time.Now().After(token.ExpiresDate) {
+		return domain.User{}, domain.AuthTokens{}, ErrInvalidRefreshToken
+	}
+
+	marked, err := s.refreshTokenRepo.MarkUsed(token.TokenHash)
+	if err != nil {
+		log.Printf("AuthService: failed to mark refresh token used %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+	if !marked {
+		return domain.User{}, domain.AuthTokens{}, s.revokeReused(sess)
 	}
 
-	token, err := s.GenerateJwt(u)
-	return u, token, err
+	user, err := s.userService.FindById(token.UserId)
+	if err != nil {
+		log.Printf("AuthService: failed to find user %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	tokens, err := s.issueTokens(sess)
+	return user, tokens, err
+}
+
+// RunRefreshTokenPurge removes expired refresh tokens every PurgeInterval until the context is done.
+func (s authService) RunRefreshTokenPurge(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		count, err := s.refreshTokenRepo.DeleteExpired()
+		if err != nil {
+			log.Printf("AuthService: failed to purge refresh tokens %s", err)
+		} else if count > 0 {
+			log.Printf("AuthService: purged %d expired refresh tokens", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s authService) revokeReused(sess domain.Session) error {
+	log.Printf("AuthService: refresh token reuse detected for user %d, revoking session", sess.UserId)
+	err := s.authRepo.Delete(sess)
+	if err != nil {
+		log.Printf("AuthService: failed to revoke session %s", err)
+		return err
+	}
+	return ErrRefreshTokenReused
 }
 
 func (s authService) Logout(sess domain.Session) error {
 	return s.authRepo.Delete(sess)
 }
 
+func (s authService) LogoutAll(userId uint64) error {
+	return s.authRepo.DeleteByUserId(userId)
+}
+
 func (s authService) ChangePassword(user domain.User, req domain.ChangePassword, sess domain.Session) error {
 	var err error
-	if !s.checkPasswordHash(req.OldPassword, user.Password) {
+	if !s.userService.CheckPassword(user, req.OldPassword) {
 		err = errors.New("invalid credentials")
 		return err
 	}
 
-	if s.checkPasswordHash(req.NewPassword, user.Password) {
+	if s.userService.CheckPassword(user, req.NewPassword) {
 		err = errors.New("old password used")
 		return err
 	}
 
+	err = s.userService.ValidatePassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
 	user.Password, err = s.userService.GeneratePasswordHash(req.NewPassword)
 	if err != nil {
 		return err
@@ -110,31 +403,114 @@ func (s authService) ChangePassword(user domain.User, req domain.ChangePassword,
 	return nil
 }
 
-func (s authService) GenerateJwt(user domain.User) (string, error) {
-	sess := domain.Session{UserId: user.Id, UUID: uuid.New()}
+func (s authService) GenerateJwt(user domain.User, client domain.Session) (domain.AuthTokens, error) {
+	sess := domain.Session{
+		UserId:    user.Id,
+		UUID:      uuid.New(),
+		UserAgent: client.UserAgent,
+		Ip:        client.Ip,
+	}
 	err := s.authRepo.Save(sess)
 	if err != nil {
 		log.Printf("AuthService: failed to save session %s", err)
-		return "", err
+		return domain.AuthTokens{}, err
 	}
 
+	return s.issueTokens(sess)
+}
+
+func (s authService) issueTokens(sess domain.Session) (domain.AuthTokens, error) {
+	expiresAt := time.Now().Add(s.config.JwtTTL)
 	claims := map[string]interface{}{
 		"user_id": sess.UserId,
 		"uuid":    sess.UUID,
 	}
-	jwtauth.SetExpiryIn(claims, s.config.JwtTTL)
-	_, tokenString, err := s.tokenAuth.Encode(claims)
+	jwtauth.SetExpiry(claims, expiresAt)
+	tokenString, err := s.tokenKeys.Sign(claims)
 	if err != nil {
-		return "", err
+		return domain.AuthTokens{}, err
+	}
+
+	refreshToken, err := generateToken()
+	if err != nil {
+		return domain.AuthTokens{}, err
 	}
+	err = s.refreshTokenRepo.Save(domain.RefreshToken{
+		TokenHash:   hashToken(refreshToken),
+		UserId:      sess.UserId,
+		SessionUUID: sess.UUID,
+		ExpiresDate: time.Now().Add(s.config.RefreshTokenTTL),
+	})
+	if err != nil {
+		log.Printf("AuthService: failed to save refresh token %s", err)
+		return domain.AuthTokens{}, err
+	}
+
+	return domain.AuthTokens{
+		AccessToken:  tokenString,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// Check returns the stored session. The last seen date is refreshed
+// at most once per sessionTouchInterval to avoid a write on every request.
+func (s authService) Check(sess domain.Session) (domain.Session, error) {
+	stored, err := s.authRepo.Find(sess)
+	if err != nil {
+		return domain.Session{}, err
+	}
+
+	if time.Since(stored.LastSeenDate) > sessionTouchInterval || (sess.Ip != "" && sess.Ip != stored.Ip) {
+		stored.Ip = sess.Ip
+		err = s.authRepo.Touch(stored)
+		if err != nil {
+			log.Printf("AuthService: failed to touch session %s", err)
+		}
+	}
+
+	return stored, nil
+}
 
-	return tokenString, nil
+func (s authService) PublicKeys() jwk.Set {
+	return s.tokenKeys.PublicSet()
 }
 
-func (s authService) Check(sess domain.Session) error {
-	return s.authRepo.Exists(sess)
+func (s authService) ListSessions(userId uint64) ([]domain.Session, error) {
+	sessions, err := s.authRepo.FindByUserId(userId)
+	if err != nil {
+		log.Printf("AuthService: failed to find sessions %s", err)
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (s authService) RevokeSession(sess domain.Session) error {
+	_, err := s.authRepo.Find(sess)
+	if err != nil {
+		return err
+	}
+	return s.authRepo.Delete(sess)
+}
+
+func (s authService) RevokeOtherSessions(sess domain.Session) error {
+	err := s.authRepo.DeleteAllExcept(sess)
+	if err != nil {
+		log.Printf("AuthService: failed to revoke sessions %s", err)
+		return err
+	}
+	return nil
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
-func (s authService) checkPasswordHash(password, hash string) bool {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"boilerplate/config"
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/mail"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrAlreadyVerified          = errors.New("email is already verified")
+)
+
+type EmailVerificationService interface {
+	SendVerification(user domain.User) error
+	Verify(token string) (domain.User, error)
+}
+
+type emailVerificationService struct {
+	userService UserService
+	mailer      mail.Mailer
+	config      config.Configuration
+}
+
+func NewEmailVerificationService(us UserService, m mail.Mailer, cf config.Configuration) EmailVerificationService {
+	return emailVerificationService{
+		userService: us,
+		mailer:      m,
+		config:      cf,
+	}
+}
+
+func (s emailVerificationService) SendVerification(user domain.User) error {
+	if user.IsVerified() {
+		return ErrAlreadyVerified
+	}
+
+	token := s.sign(user, time.Now().Add(s.config.EmailVerifyTTL))
+	link := fmt.Sprintf("%s/api/v1/auth/verify-email?token=%s", strings.TrimSuffix(s.config.AppUrl, "/"), url.QueryEscape(token))
+
+	err := s.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body:    fmt.Sprintf("Hi %s,\n\nplease confirm your email by opening the link below:\n%s\n\nThe link expires in %s.\n", user.Name, link, s.config.EmailVerifyTTL),
+	})
+	if err != nil {
+		log.Printf("EmailVerificationService: failed to send email %s", err)
+		return err
+	}
+	return nil
+}
+
+func (s emailVerificationService) Verify(token string) (domain.User, error) {
+	userId, email, err := s.parse(token)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	user, err := s.userService.FindById(userId)
+	if err != nil {
+		return domain.User{}, ErrInvalidVerificationToken
+	}
+	// the token is bound to the address it was sent to
+	if user.Email != email {
+		return domain.User{}, ErrInvalidVerificationToken
+	}
+	if user.IsVerified() {
+		return user, nil
+	}
+
+	now := time.Now()
+	user.VerifiedDate = &now
+	user, err = s.userService.Update(user, domain.User{})
+	if err != nil {
+		log.Printf("EmailVerificationService: %s", err)
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
+func (s emailVerificationService) sign(user domain.User, expires time.Time) string {
+	return signToken(s.config.JwtSecret, "email-verification", expires, strconv.FormatUint(user.Id, 10), user.Email)
+}
+
+func (s emailVerificationService) parse(token string) (uint64, string, error) {
+	fields, err := parseSignedToken(s.config.JwtSecret, "email-verification", token, 2)
+	if err != nil {
+		return 0, "", ErrInvalidVerificationToken
+	}
+	userId, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidVerificationToken
+	}
+	return userId, fields[1], nil
+}
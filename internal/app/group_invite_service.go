@@ -0,0 +1,96 @@
+package app
+
+import (
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/database"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"log"
+	"strings"
+)
+
+// inviteCodeAttempts bounds the retries on the practically impossible code collision.
+const inviteCodeAttempts = 3
+
+var ErrInvalidInvite = errors.New("invalid or expired invite code")
+
+type GroupInviteService interface {
+	Save(invite domain.GroupInvite) (domain.GroupInvite, error)
+	Find(id uint64) (interface{}, error)
+	FindByGroupId(groupId uint64) ([]domain.GroupInvite, error)
+	Revoke(invite domain.GroupInvite) error
+}
+
+type groupInviteService struct {
+	groupInviteRepo database.GroupInviteRepository
+}
+
+func NewGroupInviteService(gir database.GroupInviteRepository) GroupInviteService {
+	return groupInviteService{
+		groupInviteRepo: gir,
+	}
+}
+
+// Save creates the invite with a new random code.
+func (s groupInviteService) Save(invite domain.GroupInvite) (domain.GroupInvite, error) {
+	var err error
+	for attempt := 0; attempt < inviteCodeAttempts; attempt++ {
+		invite.Code, err = generateInviteCode()
+		if err != nil {
+			log.Printf("GroupInviteService: %s", err)
+			return domain.GroupInvite{}, err
+		}
+
+		var saved domain.GroupInvite
+		saved, err = s.groupInviteRepo.Save(invite)
+		if err == nil {
+			return saved, nil
+		}
+		if !database.IsUniqueViolation(err, database.GroupInviteCodeKey) {
+			break
+		}
+	}
+
+	log.Printf("GroupInviteService: %s", err)
+	return domain.GroupInvite{}, err
+}
+
+func (s groupInviteService) Find(id uint64) (interface{}, error) {
+	invite, err := s.groupInviteRepo.FindById(id)
+	if err != nil {
+		log.Printf("GroupInviteService: %s", err)
+		return domain.GroupInvite{}, err
+	}
+
+	return invite, err
+}
+
+func (s groupInviteService) FindByGroupId(groupId uint64) ([]domain.GroupInvite, error) {
+	invites, err := s.groupInviteRepo.FindByGroupId(groupId)
+	if err != nil {
+		log.Printf("GroupInviteService: %s", err)
+		return nil, err
+	}
+
+	return invites, err
+}
+
+func (s groupInviteService) Revoke(invite domain.GroupInvite) error {
+	err := s.groupInviteRepo.Revoke(invite.Id)
+	if err != nil {
+		log.Printf("GroupInviteService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+// generateInviteCode returns 12 lowercase base32 characters, 60 bits from the CSPRNG.
+func generateInviteCode() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.ToLower(base32.StdEncoding.EncodeToString(b))[:12], nil
+}
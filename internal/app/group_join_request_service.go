@@ -0,0 +1,100 @@
+package app
+
+import (
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/database"
+	"errors"
+	"log"
+
+	"github.com/upper/db/v4"
+)
+
+var ErrJoinRequestNotPending = errors.New("join request is not pending")
+
+type GroupJoinRequestService interface {
+	Find(id uint64) (interface{}, error)
+	FindPendingByGroupId(p domain.Pagination, groupId uint64) (domain.GroupJoinRequests, error)
+	FindPendingByUserId(userId uint64) ([]domain.GroupJoinRequest, error)
+	Approve(joinRequest domain.GroupJoinRequest, moderator domain.User) (domain.GroupMember, error)
+	Reject(joinRequest domain.GroupJoinRequest, moderator domain.User) error
+	Cancel(joinRequest domain.GroupJoinRequest) error
+}
+
+type groupJoinRequestService struct {
+	joinRequestRepo database.GroupJoinRequestRepository
+}
+
+func NewGroupJoinRequestService(gjrr database.GroupJoinRequestRepository) GroupJoinRequestService {
+	return groupJoinRequestService{
+		joinRequestRepo: gjrr,
+	}
+}
+
+func (s groupJoinRequestService) Find(id uint64) (interface{}, error) {
+	joinRequest, err := s.joinRequestRepo.FindById(id)
+	if err != nil {
+		log.Printf("GroupJoinRequestService: %s", err)
+		return domain.GroupJoinRequest{}, err
+	}
+
+	return joinRequest, err
+}
+
+func (s groupJoinRequestService) FindPendingByGroupId(p domain.Pagination, groupId uint64) (domain.GroupJoinRequests, error) {
+	joinRequests, err := s.joinRequestRepo.FindPendingByGroupId(p, groupId)
+	if err != nil {
+		log.Printf("GroupJoinRequestService: %s", err)
+		return domain.GroupJoinRequests{}, err
+	}
+
+	return joinRequests, err
+}
+
+func (s groupJoinRequestService) FindPendingByUserId(userId uint64) ([]domain.GroupJoinRequest, error) {
+	joinRequests, err := s.joinRequestRepo.FindPendingByUserId(userId)
+	if err != nil {
+		log.Printf("GroupJoinRequestService: %s", err)
+		return nil, err
+	}
+
+	return joinRequests, err
+}
+
+func (s groupJoinRequestService) Approve(joinRequest domain.GroupJoinRequest, moderator domain.User) (domain.GroupMember, error) {
+	member, err := s.joinRequestRepo.Approve(joinRequest.Id, moderator.Id)
+	if err != nil {
+		log.Printf("GroupJoinRequestService: %s", err)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.GroupMember{}, ErrJoinRequestNotPending
+		}
+		return domain.GroupMember{}, err
+	}
+
+	return member, nil
+}
+
+func (s groupJoinRequestService) Reject(joinRequest domain.GroupJoinRequest, moderator domain.User) error {
+	err := s.joinRequestRepo.Reject(joinRequest.Id, moderator.Id)
+	if err != nil {
+		log.Printf("GroupJoinRequestService: %s", err)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return ErrJoinRequestNotPending
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s groupJoinRequestService) Cancel(joinRequest domain.GroupJoinRequest) error {
+	err := s.joinRequestRepo.Cancel(joinRequest.Id)
+	if err != nil {
+		log.Printf("GroupJoinRequestService: %s", err)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return ErrJoinRequestNotPending
+		}
+		return err
+	}
+
+	return nil
+}
@@ -3,15 +3,26 @@ package app
 import (
 	"boilerplate/internal/domain"
 	"boilerplate/internal/infra/database"
+	"errors"
 	"log"
+
+	"github.com/upper/db/v4"
+)
+
+var (
+	ErrOwnerCannotLeave = errors.New("the owner can't leave the group, transfer the ownership first")
+	ErrNotGroupMember   = errors.New("you are not a member of this group")
+	ErrRoleNotInGroup   = errors.New("the role doesn't belong to this group")
+	ErrRoleNotCovered   = errors.New("you can't manage permissions you don't have")
 )
 
 type GroupMemberService interface {
-	AddGroupMember(accessCode string, userId uint64) (domain.GroupMember, error)
-	ChangeAccessLevel(groupMember domain.GroupMember, newAccessLevel string) (domain.GroupMember, error)
+	AddGroupMember(code string, userId uint64) (domain.GroupMember, domain.GroupJoinRequest, error)
+	ChangeRole(actor domain.GroupRole, groupMember domain.GroupMember, roleId uint64) (domain.GroupMember, error)
 	GetMembersList(p domain.Pagination, groupId uint64) (domain.GroupMembers, error)
 	Find(id uint64) (interface{}, error)
-	DeleteGroupMember(id uint64) error
+	DeleteGroupMember(actor domain.GroupRole, groupMember domain.GroupMember) error
+	Leave(group domain.Group, user domain.User) error
 	FindMember(uint64, uint64) (domain.GroupMember, error)
 	FindMembersByArea(p domain.Pagination, groupId uint64, points map[string]map[string]float32) (domain.GroupMembers, error)
 }
@@ -19,29 +30,58 @@ type GroupMemberService interface {
 type groupMemberService struct {
 	groupMemberRepo database.GroupMemberRepository
 	groupRepo       database.GroupRepository
+	groupRoleRepo   database.GroupRoleRepository
 	userRepo        database.UserRepository
 }
 
-func NewGroupMemberService(gmr database.GroupMemberRepository, gr database.GroupRepository, ur database.UserRepository) groupMemberService {
+func NewGroupMemberService(gmr database.GroupMemberRepository, gr database.GroupRepository, grr database.GroupRoleRepository, ur database.UserRepository) groupMemberService {
 	return groupMemberService{
 		groupMemberRepo: gmr,
 		groupRepo:       gr,
+		groupRoleRepo:   grr,
 		userRepo:        ur,
 	}
 }
 
-func (s groupMemberService) AddGroupMember(accessCode string, userId uint64) (domain.GroupMember, error) {
-	grpMember, err := s.groupMemberRepo.AddGroupMember(accessCode, userId, s.groupRepo)
+// AddGroupMember uses the invite code, the join request is returned instead of the member
+// when the group requires approval.
+func (s groupMemberService) AddGroupMember(code string, userId uint64) (domain.GroupMember, domain.GroupJoinRequest, error) {
+	grpMember, joinRequest, err := s.groupMemberRepo.AddGroupMember(code, userId)
 	if err != nil {
 		log.Printf("GroupMemberService: %s", err)
-		return domain.GroupMember{}, err
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.GroupMember{}, domain.GroupJoinRequest{}, ErrInvalidInvite
+		}
+		return domain.GroupMember{}, domain.GroupJoinRequest{}, err
 	}
 
-	return grpMember, err
+	return grpMember, joinRequest, err
 }
 
-func (s groupMemberService) ChangeAccessLevel(groupMember domain.GroupMember, newAccessLevel string) (domain.GroupMember, error) {
-	grpMember, err := s.groupMemberRepo.ChangeAccessLevel(groupMember, newAccessLevel)
+// ChangeRole assigns another role of the group to the member. The actor has to have every
+// permission of both the current and the new role, so nobody can grant more than they have.
+func (s groupMemberService) ChangeRole(actor domain.GroupRole, groupMember domain.GroupMember, roleId uint64) (domain.GroupMember, error) {
+	role, err := s.groupRoleRepo.FindById(roleId)
+	if err != nil {
+		log.Printf("GroupMemberService: %s", err)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.GroupMember{}, ErrRoleNotInGroup
+		}
+		return domain.GroupMember{}, err
+	}
+	if role.GroupId != groupMember.GroupId {
+		return domain.GroupMember{}, ErrRoleNotInGroup
+	}
+
+	err = s.checkCovers(actor, groupMember)
+	if err != nil {
+		return domain.GroupMember{}, err
+	}
+	if !actor.Covers(role) {
+		return domain.GroupMember{}, ErrRoleNotCovered
+	}
+
+	grpMember, err := s.groupMemberRepo.ChangeRole(groupMember, role.Id)
 	if err != nil {
 		log.Printf("GroupMemberService: %s", err)
 		return domain.GroupMember{}, err
@@ -60,8 +100,14 @@ func (s groupMemberService) GetMembersList(p domain.Pagination, groupId uint64)
 	return grpMembers, err
 }
 
-func (s groupMemberService) DeleteGroupMember(id uint64) error {
-	err := s.groupMemberRepo.DeleteGroupMember(id)
+// DeleteGroupMember removes the member, the actor has to have every permission of the member's role.
+func (s groupMemberService) DeleteGroupMember(actor domain.GroupRole, groupMember domain.GroupMember) error {
+	err := s.checkCovers(actor, groupMember)
+	if err != nil {
+		return err
+	}
+
+	err = s.groupMemberRepo.DeleteGroupMember(groupMember.Id)
 	if err != nil {
 		log.Printf("GroupMemberService: %s", err)
 		return err
@@ -70,6 +116,23 @@ func (s groupMemberService) DeleteGroupMember(id uint64) error {
 	return err
 }
 
+func (s groupMemberService) Leave(group domain.Group, user domain.User) error {
+	if group.UserId == user.Id {
+		return ErrOwnerCannotLeave
+	}
+
+	err := s.groupMemberRepo.Leave(user.Id, group.Id)
+	if err != nil {
+		log.Printf("GroupMemberService: %s", err)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return ErrNotGroupMember
+		}
+		return err
+	}
+
+	return nil
+}
+
 func (s groupMemberService) Find(id uint64) (interface{}, error) {
 	groupMember, err := s.groupMemberRepo.FindById(id)
 	if err != nil {
@@ -99,3 +162,15 @@ func (s groupMemberService) FindMembersByArea(p domain.Pagination, groupId uint6
 
 	return groupMembers, err
 }
+
+func (s groupMemberService) checkCovers(actor domain.GroupRole, groupMember domain.GroupMember) error {
+	current, err := s.groupRoleRepo.FindById(groupMember.RoleId)
+	if err != nil {
+		log.Printf("GroupMemberService: %s", err)
+		return err
+	}
+	if !actor.Covers(current) {
+		return ErrRoleNotCovered
+	}
+	return nil
+}
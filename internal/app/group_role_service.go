@@ -0,0 +1,104 @@
+package app
+
+import (
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/database"
+	"errors"
+	"log"
+)
+
+var (
+	ErrRoleNameTaken       = errors.New("the group already has a role with this name")
+	ErrRoleInUse           = errors.New("the role is assigned to members of the group")
+	ErrDefaultRoleDelete   = errors.New("the default role can't be deleted")
+	ErrDefaultRoleRequired = errors.New("the group needs a default role, make another role the default one instead")
+)
+
+type GroupRoleService interface {
+	Save(role domain.GroupRole) (domain.GroupRole, error)
+	Update(role domain.GroupRole, current domain.GroupRole) (domain.GroupRole, error)
+	Delete(role domain.GroupRole) error
+	Find(id uint64) (interface{}, error)
+	FindByGroupId(groupId uint64) ([]domain.GroupRole, error)
+}
+
+type groupRoleService struct {
+	groupRoleRepo database.GroupRoleRepository
+}
+
+func NewGroupRoleService(grr database.GroupRoleRepository) GroupRoleService {
+	return groupRoleService{
+		groupRoleRepo: grr,
+	}
+}
+
+func (s groupRoleService) Save(role domain.GroupRole) (domain.GroupRole, error) {
+	role, err := s.groupRoleRepo.Save(role)
+	if err != nil {
+		log.Printf("GroupRoleService: %s", err)
+		if database.IsUniqueViolation(err, database.GroupRoleNameKey) {
+			return domain.GroupRole{}, ErrRoleNameTaken
+		}
+		return domain.GroupRole{}, err
+	}
+
+	return role, nil
+}
+
+// Update replaces the role, the default role stays default until another role takes the flag.
+func (s groupRoleService) Update(role domain.GroupRole, current domain.GroupRole) (domain.GroupRole, error) {
+	if current.IsDefault && !role.IsDefault {
+		return domain.GroupRole{}, ErrDefaultRoleRequired
+	}
+
+	role.Id = current.Id
+	role.GroupId = current.GroupId
+	role.CreatedDate = current.CreatedDate
+	role, err := s.groupRoleRepo.Update(role)
+	if err != nil {
+		log.Printf("GroupRoleService: %s", err)
+		if database.IsUniqueViolation(err, database.GroupRoleNameKey) {
+			return domain.GroupRole{}, ErrRoleNameTaken
+		}
+		return domain.GroupRole{}, err
+	}
+
+	return role, nil
+}
+
+func (s groupRoleService) Delete(role domain.GroupRole) error {
+	if role.IsDefault {
+		return ErrDefaultRoleDelete
+	}
+
+	err := s.groupRoleRepo.Delete(role.Id)
+	if err != nil {
+		log.Printf("GroupRoleService: %s", err)
+		if errors.Is(err, database.ErrGroupRoleInUse) {
+			return ErrRoleInUse
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s groupRoleService) Find(id uint64) (interface{}, error) {
+	role, err := s.groupRoleRepo.FindById(id)
+	if err != nil {
+		log.Printf("GroupRoleService: %s", err)
+		return domain.GroupRole{}, err
+	}
+
+	return role, err
+}
+
+func (s groupRoleService) FindByGroupId(groupId uint64) ([]domain.GroupRole, error) {
+	roles, err := s.groupRoleRepo.FindByGroupId(groupId)
+	if err != nil {
+		log.Printf("GroupRoleService: %s", err)
+		return nil, err
+	}
+
+	return roles, err
+}
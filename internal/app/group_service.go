@@ -4,8 +4,6 @@ import (
 	"boilerplate/internal/domain"
 	"boilerplate/internal/infra/database"
 	"log"
-	"math/rand"
-	"time"
 )
 
 type GroupService interface {
@@ -13,22 +11,24 @@ type GroupService interface {
 	Update(group domain.Group) (domain.Group, error)
 	Delete(id uint64) error
 	Find(uint64) (interface{}, error)
-	GetList(p domain.Pagination) (domain.Groups, error)
-	GetAccessCode(group domain.Group) string
+	FindByMember(p domain.Pagination, userId uint64) (domain.UserGroups, error)
+	Search(p domain.Pagination, title string) (domain.Groups, error)
+	CanView(group domain.Group, userId uint64) bool
 }
 
 type groupService struct {
-	groupRepo database.GroupRepository
+	groupRepo       database.GroupRepository
+	groupMemberRepo database.GroupMemberRepository
 }
 
-func NewGroupService(gr database.GroupRepository) groupService {
+func NewGroupService(gr database.GroupRepository, gmr database.GroupMemberRepository) groupService {
 	return groupService{
-		groupRepo: gr,
+		groupRepo:       gr,
+		groupMemberRepo: gmr,
 	}
 }
 
 func (s groupService) Save(group domain.Group) (domain.Group, error) {
-	group.AcessCode = s.GenerateAccessCode()
 	grp, err := s.groupRepo.Save(group)
 	if err != nil {
 		log.Printf("GroupService: %s", err)
@@ -58,8 +58,18 @@ func (s groupService) Delete(id uint64) error {
 	return nil
 }
 
-func (s groupService) GetList(p domain.Pagination) (domain.Groups, error) {
-	groups, err := s.groupRepo.GetList(p)
+func (s groupService) FindByMember(p domain.Pagination, userId uint64) (domain.UserGroups, error) {
+	groups, err := s.groupRepo.FindByMember(p, userId)
+	if err != nil {
+		log.Printf("GroupService: %s", err)
+		return domain.UserGroups{}, err
+	}
+
+	return groups, err
+}
+
+func (s groupService) Search(p domain.Pagination, title string) (domain.Groups, error) {
+	groups, err := s.groupRepo.Search(p, title)
 	if err != nil {
 		log.Printf("GroupService: %s", err)
 		return domain.Groups{}, err
@@ -78,18 +88,13 @@ func (s groupService) Find(id uint64) (interface{}, error) {
 	return group, err
 }
 
-func (s groupService) GetAccessCode(group domain.Group) string {
-	accessCode := s.groupRepo.GetAccessCode(group)
-	return accessCode
-}
-
-func (s groupService) GenerateAccessCode() string {
-	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
-	chars := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	idLength := 6
-	id := make([]byte, idLength)
-	for i := range id {
-		id[i] = chars[rnd.Intn(len(chars))]
+// CanView reports whether the user is allowed to see the group details: the owner and
+// the members can, anyone else only when the group is listed in the directory.
+func (s groupService) CanView(group domain.Group, userId uint64) bool {
+	if group.UserId == userId || group.Discoverable {
+		return true
 	}
-	return string(id)
+
+	_, err := s.groupMemberRepo.FindMember(userId, group.Id)
+	return err == nil
 }
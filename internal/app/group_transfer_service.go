@@ -0,0 +1,131 @@
+package app
+
+import (
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/database"
+	"errors"
+	"log"
+
+	"github.com/upper/db/v4"
+)
+
+var (
+	ErrNomineeNotMember    = errors.New("the new owner must be a member of the group")
+	ErrTransferNotPending  = errors.New("ownership transfer is not pending")
+	ErrNoPendingTransfer   = errors.New("there is no pending ownership transfer")
+	ErrTransferNotPossible = errors.New("ownership transfer is not possible anymore")
+)
+
+type GroupTransferService interface {
+	Find(id uint64) (interface{}, error)
+	FindPending(group domain.Group) (domain.GroupTransfer, error)
+	FindPendingByUserId(userId uint64) ([]domain.GroupTransfer, error)
+	Nominate(group domain.Group, userId uint64) (domain.GroupTransfer, error)
+	Accept(transfer domain.GroupTransfer) error
+	Decline(transfer domain.GroupTransfer) error
+	Cancel(group domain.Group) error
+}
+
+type groupTransferService struct {
+	groupTransferRepo database.GroupTransferRepository
+}
+
+func NewGroupTransferService(gtr database.GroupTransferRepository) GroupTransferService {
+	return groupTransferService{
+		groupTransferRepo: gtr,
+	}
+}
+
+func (s groupTransferService) Find(id uint64) (interface{}, error) {
+	transfer, err := s.groupTransferRepo.FindById(id)
+	if err != nil {
+		log.Printf("GroupTransferService: %s", err)
+		return domain.GroupTransfer{}, err
+	}
+
+	return transfer, err
+}
+
+func (s groupTransferService) FindPending(group domain.Group) (domain.GroupTransfer, error) {
+	transfer, err := s.groupTransferRepo.FindPendingByGroupId(group.Id)
+	if err != nil {
+		log.Printf("GroupTransferService: %s", err)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.GroupTransfer{}, ErrNoPendingTransfer
+		}
+		return domain.GroupTransfer{}, err
+	}
+
+	return transfer, err
+}
+
+func (s groupTransferService) FindPendingByUserId(userId uint64) ([]domain.GroupTransfer, error) {
+	transfers, err := s.groupTransferRepo.FindPendingByUserId(userId)
+	if err != nil {
+		log.Printf("GroupTransferService: %s", err)
+		return nil, err
+	}
+
+	return transfers, err
+}
+
+// Nominate offers the group to one of its members, a previous pending offer is cancelled.
+func (s groupTransferService) Nominate(group domain.Group, userId uint64) (domain.GroupTransfer, error) {
+	transfer, err := s.groupTransferRepo.Save(domain.GroupTransfer{
+		GroupId:    group.Id,
+		FromUserId: group.UserId,
+		ToUserId:   userId,
+	})
+	if err != nil {
+		log.Printf("GroupTransferService: %s", err)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.GroupTransfer{}, ErrNomineeNotMember
+		}
+		return domain.GroupTransfer{}, err
+	}
+
+	return transfer, nil
+}
+
+func (s groupTransferService) Accept(transfer domain.GroupTransfer) error {
+	if transfer.Status != domain.TransferPending {
+		return ErrTransferNotPending
+	}
+
+	err := s.groupTransferRepo.Accept(transfer.Id)
+	if err != nil {
+		log.Printf("GroupTransferService: %s", err)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return ErrTransferNotPossible
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s groupTransferService) Decline(transfer domain.GroupTransfer) error {
+	err := s.groupTransferRepo.Decline(transfer.Id)
+	if err != nil {
+		log.Printf("GroupTransferService: %s", err)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return ErrTransferNotPending
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s groupTransferService) Cancel(group domain.Group) error {
+	err := s.groupTransferRepo.CancelByGroupId(group.Id)
+	if err != nil {
+		log.Printf("GroupTransferService: %s", err)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return ErrNoPendingTransfer
+		}
+		return err
+	}
+
+	return nil
+}
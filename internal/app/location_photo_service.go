@@ -0,0 +1,144 @@
+package app
+
+import (
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/database"
+	"boilerplate/internal/infra/filesystem"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+const MaxPhotoSize = 5 << 20
+
+var (
+	ErrPhotoTooLarge          = fmt.Errorf("photo is larger than %d bytes", MaxPhotoSize)
+	ErrUnsupportedContentType = errors.New("only jpeg, png and webp photos are allowed")
+)
+
+var photoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+type LocationPhotoService interface {
+	Upload(location domain.Location, userId uint64, content []byte) (domain.LocationPhoto, error)
+	Find(uint64) (interface{}, error)
+	FindByLocationId(locationId uint64) ([]domain.LocationPhoto, error)
+	Content(photo domain.LocationPhoto) ([]byte, error)
+	Delete(photo domain.LocationPhoto) error
+	DeleteByLocationId(locationId uint64) error
+}
+
+type locationPhotoService struct {
+	locationPhotoRepo  database.LocationPhotoRepository
+	fileStorageService filesystem.FileStorageService
+}
+
+func NewLocationPhotoService(lpr database.LocationPhotoRepository, fss filesystem.FileStorageService) locationPhotoService {
+	return locationPhotoService{
+		locationPhotoRepo:  lpr,
+		fileStorageService: fss,
+	}
+}
+
+func (s locationPhotoService) Upload(location domain.Location, userId uint64, content []byte) (domain.LocationPhoto, error) {
+	if len(content) > MaxPhotoSize {
+		return domain.LocationPhoto{}, ErrPhotoTooLarge
+	}
+	contentType := http.DetectContentType(content)
+	ext, ok := photoExtensions[contentType]
+	if !ok {
+		return domain.LocationPhoto{}, ErrUnsupportedContentType
+	}
+
+	photo := domain.LocationPhoto{
+		LocationId:  location.Id,
+		UserId:      userId,
+		Path:        fmt.Sprintf("locations/%d/%s%s", location.Id, uuid.New().String(), ext),
+		ContentType: contentType,
+		Size:        uint64(len(content)),
+	}
+	err := s.fileStorageService.SaveFile(photo.Path, content)
+	if err != nil {
+		log.Printf("LocationPhotoService: %s", err)
+		return domain.LocationPhoto{}, err
+	}
+
+	saved, err := s.locationPhotoRepo.Save(photo)
+	if err != nil {
+		log.Printf("LocationPhotoService: %s", err)
+		if e := s.fileStorageService.RemoveFile(photo.Path); e != nil {
+			log.Printf("LocationPhotoService: %s", e)
+		}
+		return domain.LocationPhoto{}, err
+	}
+
+	return saved, nil
+}
+
+func (s locationPhotoService) Find(id uint64) (interface{}, error) {
+	photo, err := s.locationPhotoRepo.FindById(id)
+	if err != nil {
+		log.Printf("LocationPhotoService: %s", err)
+		return domain.LocationPhoto{}, err
+	}
+
+	return photo, err
+}
+
+func (s locationPhotoService) FindByLocationId(locationId uint64) ([]domain.LocationPhoto, error) {
+	photos, err := s.locationPhotoRepo.FindByLocationId(locationId)
+	if err != nil {
+		log.Printf("LocationPhotoService: %s", err)
+		return nil, err
+	}
+
+	return photos, err
+}
+
+func (s locationPhotoService) Content(photo domain.LocationPhoto) ([]byte, error) {
+	content, err := s.fileStorageService.ReadFile(photo.Path)
+	if err != nil {
+		log.Printf("LocationPhotoService: %s", err)
+		return nil, err
+	}
+
+	return content, nil
+}
+
+func (s locationPhotoService) Delete(photo domain.LocationPhoto) error {
+	err := s.locationPhotoRepo.Delete(photo.Id)
+	if err != nil {
+		log.Printf("LocationPhotoService: %s", err)
+		return err
+	}
+
+	err = s.fileStorageService.RemoveFile(photo.Path)
+	if err != nil {
+		log.Printf("LocationPhotoService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s locationPhotoService) DeleteByLocationId(locationId uint64) error {
+	photos, err := s.FindByLocationId(locationId)
+	if err != nil {
+		return err
+	}
+
+	for _, photo := range photos {
+		err = s.Delete(photo)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
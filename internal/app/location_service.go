@@ -3,30 +3,86 @@ package app
 import (
 	"boilerplate/internal/domain"
 	"boilerplate/internal/infra/database"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/upper/db/v4"
+)
+
+var (
+	ErrLocationFull     = errors.New("location is full")
+	ErrAlreadyCheckedIn = errors.New("you are already checked in, check out first")
+	ErrNotCheckedIn     = errors.New("you are not checked in at this location")
+	ErrCapacityTooLow   = errors.New("capacity can't be lower than the current occupancy")
+	ErrEmptySearchQuery = errors.New("search query must contain letters or digits")
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrLocationNotFound = errors.New("location not found")
 )
 
 type LocationService interface {
 	Save(location domain.Location) (domain.Location, error)
-	Update(location domain.Location) (domain.Location, error)
-	Delete(id uint64) error
-	FindByArea(p domain.Pagination, points map[string]map[string]float32) (domain.Locations, error)
-	FindByUserId(p domain.Pagination, user_id uint64) (domain.Locations, error)
+	Update(location domain.Location, userId uint64) (domain.Location, error)
+	Delete(id uint64, userId uint64) error
+	FindByArea(p domain.Pagination, points map[string]map[string]float32, filter domain.LocationFilter) (domain.Locations, error)
+	FindByUserId(p domain.Pagination, user_id uint64, filter domain.LocationFilter) (domain.Locations, error)
 	Find(uint64) (interface{}, error)
+	FindNearest(search domain.NearestSearch) (domain.Locations, error)
+	CanView(location domain.Location, userId uint64) bool
+	CheckIn(location domain.Location, userId uint64) (domain.Location, error)
+	CheckOut(location domain.Location, userId uint64) (domain.Location, error)
+	GetOccupancyHistory(p domain.Pagination, locationId uint64) (domain.OccupancyEvents, error)
+	Import(locationImport domain.LocationImport, userId uint64) (domain.LocationImport, error)
+	ExportByArea(points map[string]map[string]float32, filter domain.LocationFilter, fn func(domain.Location) error) error
+	ExportByUserId(user_id uint64, filter domain.LocationFilter, fn func(domain.Location) error) error
+	Search(p domain.Pagination, search domain.LocationSearch) (domain.Locations, error)
+	GetHistory(p domain.Pagination, locationId uint64) (domain.LocationRevisions, error)
+	Revert(location domain.Location, revisionId uint64, userId uint64) (domain.Location, error)
 }
 
 type locationService struct {
-	locationRepo database.LocationRepository
+	locationRepo         database.LocationRepository
+	occupancyEventRepo   database.OccupancyEventRepository
+	locationRevisionRepo database.LocationRevisionRepository
+	locationTypeService  LocationTypeService
+	locationPhotoService LocationPhotoService
+	groupService         GroupService
+	groupMemberService   GroupMemberService
+	timezone             *time.Location
 }
 
-func NewLocationService(lr database.LocationRepository) locationService {
+func NewLocationService(lr database.LocationRepository, oer database.OccupancyEventRepository, lrr database.LocationRevisionRepository, lts LocationTypeService, lps LocationPhotoService, gs GroupService, gms GroupMemberService, tz *time.Location) locationService {
 	return locationService{
-		locationRepo: lr,
+		locationRepo:         lr,
+		occupancyEventRepo:   oer,
+		locationRevisionRepo: lrr,
+		locationTypeService:  lts,
+		locationPhotoService: lps,
+		groupService:         gs,
+		groupMemberService:   gms,
+		timezone:             tz,
 	}
 }
 
 func (s locationService) Save(location domain.Location) (domain.Location, error) {
-	loc, err := s.locationRepo.Save(location)
+	var err error
+	location.Type, err = s.locationTypeService.Validate(location.Type)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return domain.Location{}, err
+	}
+
+	err = s.checkVisibility(&location)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return domain.Location{}, err
+	}
+
+	revision := newRevision(domain.CreateRevisionAction, location.UserId, domain.Location{}, location)
+	loc, err := s.locationRepo.Save(location, revision)
 	if err != nil {
 		log.Printf("LocationService: %s", err)
 		return domain.Location{}, err
@@ -35,18 +91,59 @@ func (s locationService) Save(location domain.Location) (domain.Location, error)
 	return loc, err
 }
 
-func (s locationService) Update(location domain.Location) (domain.Location, error) {
-	loc, err := s.locationRepo.Update(location)
+// Update changes the location, a deleted one gives ErrLocationNotFound.
+func (s locationService) Update(location domain.Location, userId uint64) (domain.Location, error) {
+	old, err := s.locationRepo.FindById(location.Id)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.Location{}, ErrLocationNotFound
+		}
+		return domain.Location{}, err
+	}
+
+	location.Type, err = s.locationTypeService.Validate(location.Type)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return domain.Location{}, err
+	}
+
+	err = s.checkVisibility(&location)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return domain.Location{}, err
+	}
+
+	revision := newRevision(domain.UpdateRevisionAction, userId, old, location)
+	loc, err := s.locationRepo.Update(location, revision)
 	if err != nil {
 		log.Printf("LocationService: %s", err)
-		return loc, err
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.Location{}, ErrLocationNotFound
+		}
+		if errors.Is(err, database.ErrCapacityBelowOccupancy) {
+			return domain.Location{}, ErrCapacityTooLow
+		}
+		return domain.Location{}, err
 	}
 
 	return loc, err
 }
 
-func (s locationService) Delete(id uint64) error {
-	err := s.locationRepo.Delete(id)
+func (s locationService) Delete(id uint64, userId uint64) error {
+	old, err := s.locationRepo.FindById(id)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return err
+	}
+
+	err = s.locationRepo.Delete(id, newRevision(domain.DeleteRevisionAction, userId, old, old))
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return err
+	}
+
+	err = s.locationPhotoService.DeleteByLocationId(id)
 	if err != nil {
 		log.Printf("LocationService: %s", err)
 		return err
@@ -55,8 +152,10 @@ func (s locationService) Delete(id uint64) error {
 	return nil
 }
 
-func (s locationService) FindByArea(p domain.Pagination, points map[string]map[string]float32) (domain.Locations, error) {
-	locations, err := s.locationRepo.FindByArea(p, points)
+func (s locationService) FindByArea(p domain.Pagination, points map[string]map[string]float32, filter domain.LocationFilter) (domain.Locations, error) {
+	filter.Types = normalizeLocationTypes(filter.Types)
+	filter.OpenAt = time.Now().In(s.timezone)
+	locations, err := s.locationRepo.FindByArea(p, points, filter)
 	if err != nil {
 		log.Printf("LocationService: %s", err)
 		return domain.Locations{}, err
@@ -65,8 +164,49 @@ func (s locationService) FindByArea(p domain.Pagination, points map[string]map[s
 	return locations, err
 }
 
-func (s locationService) FindByUserId(p domain.Pagination, user_id uint64) (domain.Locations, error) {
-	locations, err := s.locationRepo.FindByUserId(p, user_id)
+func (s locationService) FindByUserId(p domain.Pagination, user_id uint64, filter domain.LocationFilter) (domain.Locations, error) {
+	filter.Types = normalizeLocationTypes(filter.Types)
+	filter.OpenAt = time.Now().In(s.timezone)
+	locations, err := s.locationRepo.FindByUserId(p, user_id, filter)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return domain.Locations{}, err
+	}
+
+	return locations, err
+}
+
+func (s locationService) ExportByArea(points map[string]map[string]float32, filter domain.LocationFilter, fn func(domain.Location) error) error {
+	filter.Types = normalizeLocationTypes(filter.Types)
+	filter.OpenAt = time.Now().In(s.timezone)
+	err := s.locationRepo.EachByArea(points, filter, fn)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s locationService) ExportByUserId(user_id uint64, filter domain.LocationFilter, fn func(domain.Location) error) error {
+	filter.Types = normalizeLocationTypes(filter.Types)
+	filter.OpenAt = time.Now().In(s.timezone)
+	err := s.locationRepo.EachByUserId(user_id, filter, fn)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s locationService) Search(p domain.Pagination, search domain.LocationSearch) (domain.Locations, error) {
+	if strings.IndexFunc(search.Text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+		return domain.Locations{}, ErrEmptySearchQuery
+	}
+	search.Filter.Types = normalizeLocationTypes(search.Filter.Types)
+	search.Filter.OpenAt = time.Now().In(s.timezone)
+	locations, err := s.locationRepo.Search(p, search)
 	if err != nil {
 		log.Printf("LocationService: %s", err)
 		return domain.Locations{}, err
@@ -82,5 +222,214 @@ func (s locationService) Find(id uint64) (interface{}, error) {
 		return domain.Location{}, err
 	}
 
+	location.Photos, err = s.locationPhotoService.FindByLocationId(id)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return domain.Location{}, err
+	}
+
 	return location, err
 }
+
+func (s locationService) FindNearest(search domain.NearestSearch) (domain.Locations, error) {
+	locations, err := s.locationRepo.FindNearest(search)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return domain.Locations{}, err
+	}
+
+	return locations, err
+}
+
+func normalizeLocationTypes(types []string) []string {
+	normalized := make([]string, 0, len(types))
+	for _, t := range types {
+		t = NormalizeLocationType(t)
+		if t != "" {
+			normalized = append(normalized, t)
+		}
+	}
+	return normalized
+}
+
+// Import validates every row against the catalogue and visibility rules and saves
+// the valid ones in one transaction, unless it is a dry run.
+func (s locationService) Import(locationImport domain.LocationImport, userId uint64) (domain.LocationImport, error) {
+	var valid []int
+	for i := range locationImport.Rows {
+		row := &locationImport.Rows[i]
+		if row.Err != nil {
+			continue
+		}
+		row.Location.UserId = userId
+		row.Location.Type, row.Err = s.locationTypeService.Validate(row.Location.Type)
+		if row.Err != nil {
+			continue
+		}
+		row.Err = s.checkVisibility(&row.Location)
+		if row.Err != nil {
+			continue
+		}
+		valid = append(valid, i)
+	}
+
+	if locationImport.DryRun || len(valid) == 0 {
+		return locationImport, nil
+	}
+
+	locations := locationImport.Valid()
+	revisions := make([]domain.LocationRevision, len(locations))
+	for i, location := range locations {
+		revisions[i] = newRevision(domain.CreateRevisionAction, userId, domain.Location{}, location)
+	}
+	locations, err := s.locationRepo.SaveBatch(locations, revisions)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return domain.LocationImport{}, err
+	}
+	for i, rowIndex := range valid {
+		locationImport.Rows[rowIndex].Location = locations[i]
+	}
+
+	return locationImport, nil
+}
+
+func (s locationService) GetHistory(p domain.Pagination, locationId uint64) (domain.LocationRevisions, error) {
+	revisions, err := s.locationRevisionRepo.GetList(p, locationId)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return domain.LocationRevisions{}, err
+	}
+
+	return revisions, err
+}
+
+// Revert restores the location state saved in the revision.
+func (s locationService) Revert(location domain.Location, revisionId uint64, userId uint64) (domain.Location, error) {
+	revision, err := s.locationRevisionRepo.FindById(revisionId)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.Location{}, ErrRevisionNotFound
+		}
+		return domain.Location{}, err
+	}
+	if revision.LocationId != location.Id {
+		return domain.Location{}, ErrRevisionNotFound
+	}
+
+	restored := revision.Snapshot
+	restored.Id = location.Id
+	restored.UserId = location.UserId
+	restored.Occupancy = location.Occupancy
+	return s.Update(restored, userId)
+}
+
+// newRevision describes a change of the location, it is saved together with the change.
+func newRevision(action string, userId uint64, old domain.Location, new domain.Location) domain.LocationRevision {
+	return domain.LocationRevision{
+		UserId:   userId,
+		Action:   action,
+		Snapshot: new,
+		Diff:     domain.DiffLocations(old, new),
+	}
+}
+
+func (s locationService) CheckIn(location domain.Location, userId uint64) (domain.Location, error) {
+	checkedIn, err := s.occupancyEventRepo.IsCheckedIn(userId)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return domain.Location{}, err
+	}
+	if checkedIn {
+		return domain.Location{}, ErrAlreadyCheckedIn
+	}
+
+	event, err := s.occupancyEventRepo.CheckIn(location.Id, userId)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.Location{}, ErrLocationFull
+		}
+		return domain.Location{}, err
+	}
+
+	location.Occupancy = event.Occupancy
+	return location, nil
+}
+
+func (s locationService) CheckOut(location domain.Location, userId uint64) (domain.Location, error) {
+	event, err := s.occupancyEventRepo.CheckOut(location.Id, userId)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.Location{}, ErrNotCheckedIn
+		}
+		return domain.Location{}, err
+	}
+
+	location.Occupancy = event.Occupancy
+	return location, nil
+}
+
+func (s locationService) GetOccupancyHistory(p domain.Pagination, locationId uint64) (domain.OccupancyEvents, error) {
+	events, err := s.occupancyEventRepo.GetHistory(p, locationId)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return domain.OccupancyEvents{}, err
+	}
+
+	return events, err
+}
+
+// CanView reports whether the user is allowed to see the location according to its visibility.
+func (s locationService) CanView(location domain.Location, userId uint64) bool {
+	if location.UserId == userId {
+		return true
+	}
+
+	visible, err := s.locationRepo.IsVisibleTo(location.Id, userId)
+	if err != nil {
+		log.Printf("LocationService: %s", err)
+		return false
+	}
+	return visible
+}
+
+func (s locationService) checkVisibility(location *domain.Location) error {
+	if location.Visibility == "" {
+		location.Visibility = domain.PrivateVisibility
+	}
+	if location.Visibility != domain.GroupsVisibility {
+		location.GroupIds = nil
+		return nil
+	}
+
+	if len(location.GroupIds) == 0 {
+		return fmt.Errorf("at least one group is required to share a location with groups")
+	}
+	for _, groupId := range location.GroupIds {
+		if !s.belongsToGroup(location.UserId, groupId) {
+			return fmt.Errorf("you are not the member of the group %d", groupId)
+		}
+	}
+
+	return nil
+}
+
+func (s locationService) belongsToGroup(userId uint64, groupId uint64) bool {
+	grp, err := s.groupService.Find(groupId)
+	if err != nil {
+		return false
+	}
+	group := grp.(domain.Group)
+	if group.DeletedDate != nil {
+		return false
+	}
+	if group.UserId == userId {
+		return true
+	}
+
+	member, err := s.groupMemberService.FindMember(userId, groupId)
+	return err == nil && member.DeletedDate == nil
+}
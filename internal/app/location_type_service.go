@@ -0,0 +1,114 @@
+package app
+
+import (
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/database"
+	"errors"
+	"log"
+	"strings"
+)
+
+var (
+	ErrUnknownLocationType = errors.New("unknown location type")
+	ErrLocationTypeTaken   = errors.New("location type with this name already exists")
+	ErrLocationTypeInUse   = errors.New("location type is used by locations")
+)
+
+type LocationTypeService interface {
+	Save(locationType domain.LocationType) (domain.LocationType, error)
+	Update(locationType domain.LocationType) (domain.LocationType, error)
+	Delete(id uint64) error
+	Find(uint64) (interface{}, error)
+	GetList(p domain.Pagination) (domain.LocationTypes, error)
+	Validate(name string) (string, error)
+}
+
+type locationTypeService struct {
+	locationTypeRepo database.LocationTypeRepository
+}
+
+func NewLocationTypeService(ltr database.LocationTypeRepository) locationTypeService {
+	return locationTypeService{
+		locationTypeRepo: ltr,
+	}
+}
+
+func (s locationTypeService) Save(locationType domain.LocationType) (domain.LocationType, error) {
+	locationType.Name = NormalizeLocationType(locationType.Name)
+	lt, err := s.locationTypeRepo.Save(locationType)
+	if err != nil {
+		log.Printf("LocationTypeService: %s", err)
+		if database.IsUniqueViolation(err, database.LocationTypeNameKey) {
+			return domain.LocationType{}, ErrLocationTypeTaken
+		}
+		return domain.LocationType{}, err
+	}
+
+	return lt, err
+}
+
+func (s locationTypeService) Update(locationType domain.LocationType) (domain.LocationType, error) {
+	locationType.Name = NormalizeLocationType(locationType.Name)
+	lt, err := s.locationTypeRepo.Update(locationType)
+	if err != nil {
+		log.Printf("LocationTypeService: %s", err)
+		if database.IsUniqueViolation(err, database.LocationTypeNameKey) {
+			return domain.LocationType{}, ErrLocationTypeTaken
+		}
+		return domain.LocationType{}, err
+	}
+
+	return lt, err
+}
+
+func (s locationTypeService) Delete(id uint64) error {
+	err := s.locationTypeRepo.Delete(id)
+	if err != nil {
+		log.Printf("LocationTypeService: %s", err)
+		if database.IsForeignKeyViolation(err, database.LocationTypeForeignKey) {
+			return ErrLocationTypeInUse
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s locationTypeService) Find(id uint64) (interface{}, error) {
+	locationType, err := s.locationTypeRepo.FindById(id)
+	if err != nil {
+		log.Printf("LocationTypeService: %s", err)
+		return domain.LocationType{}, err
+	}
+
+	return locationType, err
+}
+
+func (s locationTypeService) GetList(p domain.Pagination) (domain.LocationTypes, error) {
+	locationTypes, err := s.locationTypeRepo.GetList(p)
+	if err != nil {
+		log.Printf("LocationTypeService: %s", err)
+		return domain.LocationTypes{}, err
+	}
+
+	return locationTypes, err
+}
+
+// Validate normalizes the type name and checks that it exists in the catalogue.
+func (s locationTypeService) Validate(name string) (string, error) {
+	name = NormalizeLocationType(name)
+	exists, err := s.locationTypeRepo.Exists(name)
+	if err != nil {
+		log.Printf("LocationTypeService: %s", err)
+		return "", err
+	}
+	if !exists {
+		return "", ErrUnknownLocationType
+	}
+
+	return name, nil
+}
+
+func NormalizeLocationType(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
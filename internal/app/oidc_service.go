@@ -0,0 +1,242 @@
+package app
+
+import (
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/database"
+	"boilerplate/internal/infra/oidc"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+const oidcRequestTTL = 10 * time.Minute
+
+var (
+	ErrUnknownProvider      = errors.New("unknown identity provider")
+	ErrInvalidOidcState     = errors.New("invalid or expired login state")
+	ErrOidcEmailMissing     = errors.New("identity provider did not share an email")
+	ErrOidcEmailNotVerified = errors.New("email is not verified by the identity provider, sign in with your password instead")
+	ErrOidcAccountDeleted   = errors.New("the account with this email is deleted, restore it to sign in")
+)
+
+type OidcService interface {
+	Providers() []string
+	AuthorizationUrl(ctx context.Context, provider string) (domain.OidcLogin, error)
+	Callback(ctx context.Context, cb domain.OidcCallback, client domain.Session) (domain.User, domain.AuthTokens, error)
+}
+
+type oidcService struct {
+	providers    map[string]oidc.Provider
+	identityRepo database.UserIdentityRepository
+	userService  UserService
+	authService  AuthService
+}
+
+func NewOidcService(providers []oidc.Provider, uir database.UserIdentityRepository, us UserService, as AuthService) OidcService {
+	byName := make(map[string]oidc.Provider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+	return oidcService{
+		providers:    byName,
+		identityRepo: uir,
+		userService:  us,
+		authService:  as,
+	}
+}
+
+func (s oidcService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AuthorizationUrl starts a login. The returned binding has to come back with the callback.
+func (s oidcService) AuthorizationUrl(ctx context.Context, provider string) (domain.OidcLogin, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return domain.OidcLogin{}, ErrUnknownProvider
+	}
+
+	req := domain.OidcAuthRequest{
+		Provider:    provider,
+		ExpiresDate: time.Now().Add(oidcRequestTTL),
+	}
+	var (
+		login domain.OidcLogin
+		err   error
+	)
+	for _, v := range []*string{&req.State, &req.Nonce, &req.CodeVerifier, &login.Binding} {
+		*v, err = generateToken()
+		if err != nil {
+			return domain.OidcLogin{}, err
+		}
+	}
+	req.BindingHash = hashToken(login.Binding)
+
+	err = s.identityRepo.SaveAuthRequest(req)
+	if err != nil {
+		log.Printf("OidcService: %s", err)
+		return domain.OidcLogin{}, err
+	}
+
+	login.Url, err = p.AuthCodeUrl(ctx, req.State, req.Nonce, req.CodeVerifier)
+	if err != nil {
+		return domain.OidcLogin{}, err
+	}
+	return login, nil
+}
+
+// Callback finishes the login. A known identity logs its user in, otherwise the identity
+// is linked to the account with the same email, but only if the provider verified the email.
+func (s oidcService) Callback(ctx context.Context, cb domain.OidcCallback, client domain.Session) (domain.User, domain.AuthTokens, error) {
+	p, ok := s.providers[cb.Provider]
+	if !ok {
+		return domain.User{}, domain.AuthTokens{}, ErrUnknownProvider
+	}
+
+	if cb.Binding == "" {
+		return domain.User{}, domain.AuthTokens{}, ErrInvalidOidcState
+	}
+	req, err := s.identityRepo.ConsumeAuthRequest(cb.State, hashToken(cb.Binding))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.User{}, domain.AuthTokens{}, ErrInvalidOidcState
+		}
+		log.Printf("OidcService: %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+	if req.Provider != cb.Provider || time.Now().After(req.ExpiresDate) {
+		return domain.User{}, domain.AuthTokens{}, ErrInvalidOidcState
+	}
+
+	claims, err := p.Exchange(ctx, cb.Code, req.CodeVerifier, req.Nonce)
+	if err != nil {
+		log.Printf("OidcService: %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	user, err := s.findOrLinkUser(cb.Provider, claims)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	tokens, err := s.authService.CompleteLogin(user, client)
+	return user, tokens, err
+}
+
+func (s oidcService) findOrLinkUser(provider string, claims oidc.Claims) (domain.User, error) {
+	identity, err := s.identityRepo.FindByProviderSubject(provider, claims.Subject)
+	if err == nil {
+		return s.userService.FindById(identity.UserId)
+	}
+	if !errors.Is(err, db.ErrNoMoreRows) {
+		log.Printf("OidcService: %s", err)
+		return domain.User{}, err
+	}
+
+	if claims.Email == "" {
+		return domain.User{}, ErrOidcEmailMissing
+	}
+
+	user, err := s.userService.FindByEmail(claims.Email)
+	switch {
+	case err == nil:
+		if !claims.EmailVerified {
+			return domain.User{}, ErrOidcEmailNotVerified
+		}
+		if !user.IsVerified() {
+			user, err = s.takeOverUnverified(user)
+			if err != nil {
+				return domain.User{}, err
+			}
+		}
+	case errors.Is(err, db.ErrNoMoreRows):
+		user, err = s.createUser(claims)
+		if err != nil {
+			return domain.User{}, err
+		}
+	default:
+		return domain.User{}, err
+	}
+
+	_, err = s.identityRepo.Save(domain.UserIdentity{
+		UserId:   user.Id,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+	if err != nil {
+		log.Printf("OidcService: %s", err)
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
+// takeOverUnverified secures an account that was registered with the email but never
+// confirmed, whoever registered it may not own the address, so their password and
+// sessions are dropped before the owner proven by the provider gets in.
+func (s oidcService) takeOverUnverified(user domain.User) (domain.User, error) {
+	password, err := generateToken()
+	if err != nil {
+		return domain.User{}, err
+	}
+	user.Password, err = s.userService.GeneratePasswordHash(password)
+	if err != nil {
+		return domain.User{}, err
+	}
+	now := time.Now()
+	user.VerifiedDate = &now
+
+	user, err = s.userService.Update(user, domain.User{})
+	if err != nil {
+		return domain.User{}, err
+	}
+	err = s.authService.LogoutAll(user.Id)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
+func (s oidcService) createUser(claims oidc.Claims) (domain.User, error) {
+	// the email stays taken by a deleted account until it is purged, so it can still be restored
+	_, err := s.userService.FindDeletedByEmail(claims.Email)
+	if err == nil {
+		return domain.User{}, ErrOidcAccountDeleted
+	} else if !errors.Is(err, db.ErrNoMoreRows) {
+		log.Printf("OidcService: %s", err)
+		return domain.User{}, err
+	}
+
+	// the account has no usable password until the user resets it
+	password, err := generateToken()
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+	user := domain.User{
+		Name:     name,
+		Email:    claims.Email,
+		Password: password,
+	}
+	if claims.EmailVerified {
+		now := time.Now()
+		user.VerifiedDate = &now
+	}
+
+	return s.userService.Save(user)
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"boilerplate/config"
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/database"
+	"boilerplate/internal/infra/mail"
+	"boilerplate/internal/infra/ratelimit"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+// maxPendingResets limits how many reset links are being sent at the same time.
+const maxPendingResets = 8
+
+type PasswordResetService interface {
+	RequestReset(email string, client domain.Session)
+	Reset(req domain.PasswordReset) error
+}
+
+type passwordResetService struct {
+	resetTokenRepo database.PasswordResetTokenRepository
+	userService    UserService
+	mailer         mail.Mailer
+	emailLimiter   ratelimit.Limiter
+	ipLimiter      ratelimit.Limiter
+	pending        chan struct{}
+	config         config.Configuration
+}
+
+func NewPasswordResetService(prtr database.PasswordResetTokenRepository, us UserService, m mail.Mailer, el ratelimit.Limiter, il ratelimit.Limiter, cf config.Configuration) PasswordResetService {
+	return passwordResetService{
+		resetTokenRepo: prtr,
+		userService:    us,
+		mailer:         m,
+		emailLimiter:   el,
+		ipLimiter:      il,
+		pending:        make(chan struct{}, maxPendingResets),
+		config:         cf,
+	}
+}
+
+// RequestReset sends a reset link if the email is registered. It reports nothing
+// back and does the work in the background, so the caller can't tell whether the
+// email exists neither by the response nor by its timing. Requests over the limit
+// of the email or the client address, or over the pending links, are dropped.
+func (s passwordResetService) RequestReset(email string, client domain.Session) {
+	emailKey := strings.ToLower(email)
+	if max(s.emailLimiter.Check(emailKey), s.ipLimiter.Check(client.Ip)) > 0 {
+		log.Printf("PasswordResetService: too many reset requests from %s", client.Ip)
+		return
+	}
+	s.emailLimiter.Fail(emailKey)
+	s.ipLimiter.Fail(client.Ip)
+
+	select {
+	case s.pending <- struct{}{}:
+	default:
+		log.Printf("PasswordResetService: too many pending reset requests, dropping one from %s", client.Ip)
+		return
+	}
+	go func() {
+		defer func() { <-s.pending }()
+		err := s.sendResetLink(email)
+		if err != nil {
+			log.Printf("PasswordResetService: %s", err)
+		}
+	}()
+}
+
+func (s passwordResetService) sendResetLink(email string) error {
+	user, err := s.userService.FindByEmail(email)
+	if err != nil {
+		return err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return err
+	}
+	err = s.resetTokenRepo.Save(domain.PasswordResetToken{
+		TokenHash:   hashToken(token),
+		UserId:      user.Id,
+		ExpiresDate: time.Now().Add(s.config.PasswordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimSuffix(s.config.AppUrl, "/"), url.QueryEscape(token))
+	return s.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Hi %s,\n\nsomeone requested a password reset for your account. To set a new password open the link below:\n%s\n\nThe link expires in %s. If it wasn't you, just ignore this email.\n", user.Name, link, s.config.PasswordResetTTL),
+	})
+}
+
+func (s passwordResetService) Reset(req domain.PasswordReset) error {
+	err := s.userService.ValidatePassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	token, err := s.resetTokenRepo.FindByHash(hashToken(req.Token))
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+	if token.UsedDate != nil || time.Now().After(token.ExpiresDate) {
+		return ErrInvalidResetToken
+	}
+
+	passwordHash, err := s.userService.GeneratePasswordHash(req.NewPassword)
+	if err != nil {
+		log.Printf("PasswordResetService: %s", err)
+		return err
+	}
+
+	err = s.resetTokenRepo.Redeem(token.TokenHash, token.UserId, passwordHash)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return ErrInvalidResetToken
+		}
+		log.Printf("PasswordResetService: %s", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errInvalidSignedToken = errors.New("invalid signed token")
+
+// signToken builds a stateless token of the form base64(payload).base64(hmac),
+// where payload is "expires:field1:...:fieldN". The purpose is mixed into the
+// signature, so a token issued for one flow is never accepted by another.
+func signToken(secret, purpose string, expires time.Time, fields ...string) string {
+	payload := strings.Join(append([]string{strconv.FormatInt(expires.Unix(), 10)}, fields...), ":")
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(tokenMac(secret, purpose, payload))
+}
+
+// parseSignedToken checks the signature and expiry and returns n fields,
+// the last one may contain colons.
+func parseSignedToken(secret, purpose, token string, n int) ([]string, error) {
+	encPayload, encSig, found := strings.Cut(token, ".")
+	if !found {
+		return nil, errInvalidSignedToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return nil, errInvalidSignedToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encSig)
+	if err != nil || !hmac.Equal(sig, tokenMac(secret, purpose, string(payload))) {
+		return nil, errInvalidSignedToken
+	}
+
+	parts := strings.SplitN(string(payload), ":", n+1)
+	if len(parts) != n+1 {
+		return nil, errInvalidSignedToken
+	}
+	expires, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return nil, errInvalidSignedToken
+	}
+
+	return parts[1:], nil
+}
+
+func tokenMac(secret, purpose, payload string) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(purpose + ":" + payload))
+	return h.Sum(nil)
+}
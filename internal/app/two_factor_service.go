@@ -0,0 +1,223 @@
+package app
+
+import (
+	"boilerplate/config"
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/database"
+	"boilerplate/internal/infra/encryption"
+	"boilerplate/internal/infra/totp"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+const recoveryCodesCount = 10
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+)
+
+type TwoFactorService interface {
+	IsEnabled(userId uint64) (bool, error)
+	Enroll(user domain.User) (domain.TwoFactorEnrollment, error)
+	Confirm(user domain.User, code string, sess domain.Session) ([]string, error)
+	Disable(user domain.User, code string) error
+	Verify(userId uint64, code string) error
+}
+
+type twoFactorService struct {
+	twoFactorRepo database.TwoFactorRepository
+	sessionRepo   database.SessionRepository
+	cipher        encryption.Cipher
+	config        config.Configuration
+}
+
+func NewTwoFactorService(tfr database.TwoFactorRepository, sr database.SessionRepository, c encryption.Cipher, cf config.Configuration) TwoFactorService {
+	return twoFactorService{
+		twoFactorRepo: tfr,
+		sessionRepo:   sr,
+		cipher:        c,
+		config:        cf,
+	}
+}
+
+func (s twoFactorService) IsEnabled(userId uint64) (bool, error) {
+	tf, err := s.twoFactorRepo.Find(userId)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return false, nil
+		}
+		log.Printf("TwoFactorService: %s", err)
+		return false, err
+	}
+	return tf.IsEnabled(), nil
+}
+
+// Enroll generates a new secret, it takes effect only after Confirm.
+func (s twoFactorService) Enroll(user domain.User) (domain.TwoFactorEnrollment, error) {
+	enabled, err := s.IsEnabled(user.Id)
+	if err != nil {
+		return domain.TwoFactorEnrollment{}, err
+	}
+	if enabled {
+		return domain.TwoFactorEnrollment{}, ErrTwoFactorEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return domain.TwoFactorEnrollment{}, err
+	}
+	encrypted, err := s.cipher.Encrypt([]byte(secret))
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return domain.TwoFactorEnrollment{}, err
+	}
+
+	err = s.twoFactorRepo.Save(domain.TwoFactor{UserId: user.Id, Secret: encrypted})
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return domain.TwoFactorEnrollment{}, err
+	}
+
+	return domain.TwoFactorEnrollment{
+		Secret: secret,
+		Uri:    totp.Uri(s.config.TotpIssuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm enables two-factor authentication and returns the recovery codes,
+// they are shown only once.
+// Confirm enables two-factor authentication and signs out every other session, so a
+// session stolen before can't outlive the second factor. Their refresh tokens go with them.
+func (s twoFactorService) Confirm(user domain.User, code string, sess domain.Session) ([]string, error) {
+	tf, err := s.twoFactorRepo.Find(user.Id)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return nil, ErrTwoFactorNotEnabled
+		}
+		return nil, err
+	}
+	if tf.IsEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	step, ok, err := s.validate(tf, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+	for i := range codes {
+		codes[i], err = generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+
+	err = s.twoFactorRepo.Enable(user.Id, step, hashes)
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return nil, err
+	}
+
+	err = s.sessionRepo.DeleteAllExcept(sess)
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s twoFactorService) Disable(user domain.User, code string) error {
+	err := s.Verify(user.Id, code)
+	if err != nil {
+		return err
+	}
+
+	err = s.twoFactorRepo.Delete(user.Id)
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return err
+	}
+	return nil
+}
+
+// Verify accepts either a current TOTP code or an unused recovery code.
+func (s twoFactorService) Verify(userId uint64, code string) error {
+	tf, err := s.twoFactorRepo.Find(userId)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return ErrTwoFactorNotEnabled
+		}
+		return err
+	}
+	if !tf.IsEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok, err := s.validate(tf, code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		used, err := s.twoFactorRepo.UseStep(userId, step)
+		if err != nil {
+			log.Printf("TwoFactorService: %s", err)
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := s.twoFactorRepo.UseRecoveryCode(userId, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s twoFactorService) validate(tf domain.TwoFactor, code string) (int64, bool, error) {
+	secret, err := s.cipher.Decrypt(tf.Secret)
+	if err != nil {
+		log.Printf("TwoFactorService: failed to decrypt secret %s", err)
+		return 0, false, err
+	}
+	step, ok := totp.Validate(string(secret), code, time.Now())
+	return step, ok, nil
+}
+
+// generateRecoveryCode returns a code like "abcde-fghij".
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -3,29 +3,54 @@ package app
 import (
 	"boilerplate/internal/domain"
 	"boilerplate/internal/infra/database"
+	"boilerplate/internal/infra/password"
 	"log"
-
-	"golang.org/x/crypto/bcrypt"
+	"time"
 )
 
+// WeakPasswordError is returned for a new password that doesn't satisfy the password policy.
+type WeakPasswordError struct {
+	Reason error
+}
+
+func (e WeakPasswordError) Error() string {
+	return e.Reason.Error()
+}
+
 type UserService interface {
 	FindByEmail(email string) (domain.User, error)
 	Save(user domain.User) (domain.User, error)
 	FindById(id uint64) (domain.User, error)
+	FindDeletedByEmail(email string) (domain.User, error)
 	Update(user domain.User, req domain.User) (domain.User, error)
 	Delete(id uint64) error
+	Restore(id uint64, deletedAfter time.Time) error
+	ValidatePassword(password string) error
 	GeneratePasswordHash(password string) (string, error)
+	CheckPassword(user domain.User, password string) bool
+	UpgradePasswordHash(user domain.User, password string)
 	GetCoordinates(user domain.User) (float32, float32, error)
 	SetCoordinates(lat float32, lon float32, user domain.User) error
 }
 
 type userService struct {
-	userRepo database.UserRepository
+	userRepo  database.UserRepository
+	hasher    password.Hasher
+	policy    password.Policy
+	dummyHash string
 }
 
-func NewUserService(ur database.UserRepository) UserService {
+func NewUserService(ur database.UserRepository, h password.Hasher, p password.Policy) UserService {
+	// checked instead of a missing password, so an unknown email takes as long as a wrong password
+	dummyHash, err := h.Hash("dummy password")
+	if err != nil {
+		log.Fatalf("UserService: %s", err)
+	}
 	return userService{
-		userRepo: ur,
+		userRepo:  ur,
+		hasher:    h,
+		policy:    p,
+		dummyHash: dummyHash,
 	}
 }
 
@@ -42,6 +67,7 @@ func (s userService) FindByEmail(email string) (domain.User, error) {
 func (s userService) Save(user domain.User) (domain.User, error) {
 	var err error
 
+	user.Role = domain.UserRole
 	user.Password, err = s.GeneratePasswordHash(user.Password)
 	if err != nil {
 		log.Printf("UserService: %s", err)
@@ -67,6 +93,16 @@ func (s userService) FindById(id uint64) (domain.User, error) {
 	return user, err
 }
 
+func (s userService) FindDeletedByEmail(email string) (domain.User, error) {
+	user, err := s.userRepo.FindDeletedByEmail(email)
+	if err != nil {
+		log.Printf("UserService: %s", err)
+		return domain.User{}, err
+	}
+
+	return user, err
+}
+
 func (s userService) Update(user domain.User, req domain.User) (domain.User, error) {
 	user, err := s.userRepo.Update(user)
 	if err != nil {
@@ -87,9 +123,57 @@ func (s userService) Delete(id uint64) error {
 	return nil
 }
 
+func (s userService) Restore(id uint64, deletedAfter time.Time) error {
+	err := s.userRepo.Restore(id, deletedAfter)
+	if err != nil {
+		log.Printf("UserService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+// ValidatePassword checks a new password against the password policy.
+func (s userService) ValidatePassword(password string) error {
+	err := s.policy.Validate(password)
+	if err != nil {
+		return WeakPasswordError{Reason: err}
+	}
+
+	return nil
+}
+
 func (s userService) GeneratePasswordHash(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+	return s.hasher.Hash(password)
+}
+
+// CheckPassword verifies the password of the user. A user without a password, like the
+// zero user of an unknown email, never matches but costs the same time as a real check.
+func (s userService) CheckPassword(user domain.User, password string) bool {
+	if user.Password == "" {
+		s.hasher.Verify(password, s.dummyHash)
+		return false
+	}
+	return s.hasher.Verify(password, user.Password)
+}
+
+// UpgradePasswordHash rehashes a verified password when its hash is outdated, e.g. still bcrypt.
+// A failure only keeps the old hash, so it is logged and not returned.
+func (s userService) UpgradePasswordHash(user domain.User, password string) {
+	if !s.hasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		log.Printf("UserService: %s", err)
+		return
+	}
+	user.Password = hash
+	_, err = s.userRepo.Update(user)
+	if err != nil {
+		log.Printf("UserService: %s", err)
+	}
 }
 
 func (s userService) GetCoordinates(user domain.User) (float32, float32, error) {
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// AccountExport is everything stored about a user, handed out on request.
+type AccountExport struct {
+	User            User
+	Sessions        []Session
+	Identities      []UserIdentity
+	Locations       []Location
+	Groups          []Group
+	Memberships     []GroupMember
+	OccupancyEvents []OccupancyEvent
+	GeneratedDate   time.Time
+}
@@ -2,15 +2,19 @@ package domain
 
 import "time"
 
+// GroupOwnerRole is the role name reported for the owner of the group, who is not a member.
+const GroupOwnerRole = "owner"
+
 type Group struct {
-	Id          uint64
-	Title       string
-	Description string
-	UserId      uint64
-	AcessCode   string
-	CreatedDate time.Time
-	UpdatedDate time.Time
-	DeletedDate *time.Time
+	Id               uint64
+	Title            string
+	Description      string
+	UserId           uint64
+	RequiresApproval bool
+	Discoverable     bool
+	CreatedDate      time.Time
+	UpdatedDate      time.Time
+	DeletedDate      *time.Time
 }
 
 type Groups struct {
@@ -19,6 +23,18 @@ type Groups struct {
 	Pages uint
 }
 
+// UserGroup is a group together with the role of the user it was listed for.
+type UserGroup struct {
+	Group
+	Role GroupRole
+}
+
+type UserGroups struct {
+	Items []UserGroup
+	Total uint64
+	Pages uint
+}
+
 func (group Group) GetUserId() uint64 {
 	return group.UserId
 }
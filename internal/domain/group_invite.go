@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// GroupInvite is a code to join a group. MaxUses of 0 means unlimited, ExpiresDate of nil never expires.
+type GroupInvite struct {
+	Id          uint64
+	GroupId     uint64
+	UserId      uint64
+	Code        string
+	MaxUses     uint64
+	Uses        uint64
+	ExpiresDate *time.Time
+	RevokedDate *time.Time
+	CreatedDate time.Time
+}
+
+func (i GroupInvite) IsActive(now time.Time) bool {
+	return i.RevokedDate == nil &&
+		(i.ExpiresDate == nil || now.Before(*i.ExpiresDate)) &&
+		(i.MaxUses == 0 || i.Uses < i.MaxUses)
+}
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+const (
+	JoinRequestPending   = "pending"
+	JoinRequestApproved  = "approved"
+	JoinRequestRejected  = "rejected"
+	JoinRequestCancelled = "cancelled"
+)
+
+// GroupJoinRequest is created instead of a membership when the group requires approval.
+type GroupJoinRequest struct {
+	Id          uint64
+	GroupId     uint64
+	UserId      uint64
+	Status      string
+	DecidedBy   *uint64
+	DecidedDate *time.Time
+	CreatedDate time.Time
+	UpdatedDate time.Time
+}
+
+type GroupJoinRequests struct {
+	Items []GroupJoinRequest
+	Total uint64
+	Pages uint
+}
+
+func (r GroupJoinRequest) GetUserId() uint64 {
+	return r.UserId
+}
@@ -0,0 +1,95 @@
+package domain
+
+import "time"
+
+type Permission string
+
+const (
+	MembersViewPermission    Permission = "members.view"
+	MembersLocatePermission  Permission = "members.locate"
+	MembersApprovePermission Permission = "members.approve"
+	MembersManagePermission  Permission = "members.manage"
+	GroupEditPermission      Permission = "group.edit"
+	AlertsSendPermission     Permission = "alerts.send"
+)
+
+// Permissions lists every permission a group role can grant.
+var Permissions = []Permission{
+	MembersViewPermission,
+	MembersLocatePermission,
+	MembersApprovePermission,
+	MembersManagePermission,
+	GroupEditPermission,
+	AlertsSendPermission,
+}
+
+func (p Permission) IsValid() bool {
+	for _, permission := range Permissions {
+		if permission == p {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupRole bundles permissions for the members of one group. New members get the default role.
+type GroupRole struct {
+	Id          uint64
+	GroupId     uint64
+	Name        string
+	Permissions []Permission
+	IsDefault   bool
+	CreatedDate time.Time
+	UpdatedDate time.Time
+}
+
+func (r GroupRole) Can(permission Permission) bool {
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether the role has every permission of the other role.
+func (r GroupRole) Covers(other GroupRole) bool {
+	for _, p := range other.Permissions {
+		if !r.Can(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// OwnerRole is what the owner of the group acts with, the owner is not a member and can do everything.
+func OwnerRole(groupId uint64) GroupRole {
+	return GroupRole{
+		GroupId:     groupId,
+		Name:        GroupOwnerRole,
+		Permissions: append([]Permission{}, Permissions...),
+	}
+}
+
+// DefaultGroupRoles are created with every group, they match the access levels
+// groups had before roles could be customized, admins can also edit the group and send alerts.
+func DefaultGroupRoles(groupId uint64) []GroupRole {
+	return []GroupRole{
+		{
+			GroupId:     groupId,
+			Name:        "casual",
+			Permissions: []Permission{},
+			IsDefault:   true,
+		},
+		{
+			GroupId:     groupId,
+			Name:        "moderator",
+			Permissions: []Permission{MembersViewPermission, MembersLocatePermission, MembersApprovePermission},
+		},
+		{
+			GroupId:     groupId,
+			Name:        "admin",
+			Permissions: []Permission{MembersViewPermission, MembersLocatePermission, MembersApprovePermission, MembersManagePermission, GroupEditPermission, AlertsSendPermission},
+		},
+	}
+}
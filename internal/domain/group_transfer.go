@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+const (
+	TransferPending   = "pending"
+	TransferAccepted  = "accepted"
+	TransferDeclined  = "declined"
+	TransferCancelled = "cancelled"
+)
+
+// GroupTransfer hands the group over from the owner to one of its members once the member accepts.
+type GroupTransfer struct {
+	Id          uint64
+	GroupId     uint64
+	FromUserId  uint64
+	ToUserId    uint64
+	Status      string
+	DecidedDate *time.Time
+	CreatedDate time.Time
+	UpdatedDate time.Time
+}
+
+// GetUserId returns the nominee, who is the one to accept or decline the transfer.
+func (t GroupTransfer) GetUserId() uint64 {
+	return t.ToUserId
+}
@@ -5,17 +5,25 @@ import (
 )
 
 type Location struct {
-	Id          uint64
-	UserId      uint64
-	Type        string
-	Address     string
-	Title       string
-	Description string
-	Lat         float64
-	Lon         float64
-	CreatedDate time.Time
-	UpdatedDate time.Time
-	DeletedDate *time.Time
+	Id           uint64
+	UserId       uint64
+	Type         string
+	Address      string
+	Title        string
+	Description  string
+	Lat          float64
+	Lon          float64
+	Visibility   string
+	Capacity     uint64
+	Occupancy    uint64
+	OpeningHours OpeningHours
+	Attributes   []string
+	Photos       []LocationPhoto
+	GroupIds     []uint64
+	Distance     *float64
+	CreatedDate  time.Time
+	UpdatedDate  time.Time
+	DeletedDate  *time.Time
 }
 
 type Locations struct {
@@ -24,6 +32,58 @@ type Locations struct {
 	Pages uint
 }
 
+type OpeningHours struct {
+	AlwaysOpen bool
+	Periods    []OpeningPeriod
+}
+
+// OpeningPeriod describes working hours for one weekday in "15:04" format.
+// A period with Closes before Opens lasts past midnight.
+type OpeningPeriod struct {
+	Weekday time.Weekday
+	Opens   string
+	Closes  string
+}
+
+const (
+	WheelchairAccessibleAttribute = "wheelchair_accessible"
+	WaterAttribute                = "water"
+	PowerAttribute                = "power"
+	WifiAttribute                 = "wifi"
+	PetFriendlyAttribute          = "pet_friendly"
+)
+
+const (
+	PrivateVisibility = "private"
+	GroupsVisibility  = "groups"
+	PublicVisibility  = "public"
+)
+
+type NearestSearch struct {
+	Lat      float64
+	Lon      float64
+	Radius   float64
+	Limit    uint64
+	ViewerId uint64
+}
+
+type LocationFilter struct {
+	Types      []string
+	Attributes []string
+	OpenNow    bool
+	// OpenAt is the local time used for the OpenNow check.
+	OpenAt time.Time
+	// ViewerId limits the result to locations visible to this user, 0 disables the check.
+	ViewerId uint64
+}
+
+// LocationSearch is a full-text query, Points optionally limit it to an area.
+type LocationSearch struct {
+	Text   string
+	Points map[string]map[string]float32
+	Filter LocationFilter
+}
+
 func (loc Location) GetUserId() uint64 {
 	return loc.UserId
 }
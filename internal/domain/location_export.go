@@ -0,0 +1,8 @@
+package domain
+
+// Formats locations can be exported in.
+const (
+	JsonFormat    = "json"
+	GeoJsonFormat = "geojson"
+	GpxFormat     = "gpx"
+)
@@ -0,0 +1,24 @@
+package domain
+
+type LocationImport struct {
+	DryRun bool
+	Rows   []LocationImportRow
+}
+
+// LocationImportRow is one record of an imported file, Row is 1-based and
+// does not count the CSV header.
+type LocationImportRow struct {
+	Row      int
+	Location Location
+	Err      error
+}
+
+func (i LocationImport) Valid() []Location {
+	var locations []Location
+	for _, row := range i.Rows {
+		if row.Err == nil {
+			locations = append(locations, row.Location)
+		}
+	}
+	return locations
+}
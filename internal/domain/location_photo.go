@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+type LocationPhoto struct {
+	Id          uint64
+	LocationId  uint64
+	UserId      uint64
+	Path        string
+	ContentType string
+	Size        uint64
+	CreatedDate time.Time
+}
+
+func (p LocationPhoto) GetUserId() uint64 {
+	return p.UserId
+}
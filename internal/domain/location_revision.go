@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"reflect"
+	"time"
+)
+
+const (
+	CreateRevisionAction = "create"
+	UpdateRevisionAction = "update"
+	DeleteRevisionAction = "delete"
+)
+
+// LocationRevision is a recorded change of a location. Snapshot holds the
+// location state after the change, Diff only the changed fields.
+type LocationRevision struct {
+	Id          uint64
+	LocationId  uint64
+	UserId      uint64
+	Action      string
+	Snapshot    Location
+	Diff        map[string]LocationFieldChange
+	CreatedDate time.Time
+}
+
+type LocationRevisions struct {
+	Items []LocationRevision
+	Total uint64
+	Pages uint
+}
+
+type LocationFieldChange struct {
+	Old interface{}
+	New interface{}
+}
+
+// DiffLocations compares the editable fields of two location states.
+func DiffLocations(old Location, new Location) map[string]LocationFieldChange {
+	fields := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"type", old.Type, new.Type},
+		{"address", old.Address, new.Address},
+		{"title", old.Title, new.Title},
+		{"description", old.Description, new.Description},
+		{"lat", old.Lat, new.Lat},
+		{"lon", old.Lon, new.Lon},
+		{"visibility", old.Visibility, new.Visibility},
+		{"group_ids", old.GroupIds, new.GroupIds},
+		{"capacity", old.Capacity, new.Capacity},
+		{"opening_hours", old.OpeningHours, new.OpeningHours},
+		{"attributes", old.Attributes, new.Attributes},
+	}
+
+	diff := make(map[string]LocationFieldChange)
+	for _, f := range fields {
+		if !equalValues(f.old, f.new) {
+			diff[f.name] = LocationFieldChange{Old: f.old, New: f.new}
+		}
+	}
+	return diff
+}
+
+// equalValues treats nil and empty slices as equal.
+func equalValues(a, b interface{}) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Kind() == reflect.Slice && vb.Kind() == reflect.Slice && va.Len() == 0 && vb.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+type LocationType struct {
+	Id          uint64
+	Name        string
+	Title       string
+	CreatedDate time.Time
+	UpdatedDate time.Time
+}
+
+type LocationTypes struct {
+	Items []LocationType
+	Total uint64
+	Pages uint
+}
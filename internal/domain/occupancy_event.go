@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+const (
+	CheckInAction  = "check_in"
+	CheckOutAction = "check_out"
+)
+
+type OccupancyEvent struct {
+	Id          uint64
+	LocationId  uint64
+	UserId      uint64
+	Action      string
+	Occupancy   uint64
+	CreatedDate time.Time
+}
+
+type OccupancyEvents struct {
+	Items []OccupancyEvent
+	Total uint64
+	Pages uint
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// PasswordResetToken is a single-use token sent by email, only its hash is stored.
+type PasswordResetToken struct {
+	TokenHash   string
+	UserId      uint64
+	ExpiresDate time.Time
+	UsedDate    *time.Time
+	CreatedDate time.Time
+}
+
+type PasswordReset struct {
+	Token       string
+	NewPassword string
+}
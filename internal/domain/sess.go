@@ -1,10 +1,36 @@
 package domain
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
 type Session struct {
-	UserId uint64
-	UUID   uuid.UUID
+	UserId       uint64
+	UUID         uuid.UUID
+	UserAgent    string
+	Ip           string
+	CreatedDate  time.Time
+	LastSeenDate time.Time
+}
+
+// RefreshToken belongs to a session, a new one is issued on every refresh.
+// Only the hash of the token is stored.
+type RefreshToken struct {
+	TokenHash   string
+	UserId      uint64
+	SessionUUID uuid.UUID
+	ExpiresDate time.Time
+	UsedDate    *time.Time
+	CreatedDate time.Time
+}
+
+// AuthTokens holds either the issued tokens or, when the user has
+// two-factor authentication enabled, a challenge token for the second step.
+type AuthTokens struct {
+	AccessToken    string
+	RefreshToken   string
+	ExpiresAt      time.Time
+	ChallengeToken string
 }
@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// TwoFactor holds the TOTP settings of a user. Secret is the encrypted value
+// as stored, it is decrypted only when a code has to be checked.
+type TwoFactor struct {
+	UserId       uint64
+	Secret       string
+	LastUsedStep int64
+	EnabledDate  *time.Time
+	CreatedDate  time.Time
+}
+
+func (t TwoFactor) IsEnabled() bool {
+	return t.EnabledDate != nil
+}
+
+type TwoFactorEnrollment struct {
+	Secret string
+	Uri    string
+}
+
+type TwoFactorLogin struct {
+	ChallengeToken string
+	Code           string
+}
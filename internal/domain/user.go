@@ -5,15 +5,17 @@ import (
 )
 
 type User struct {
-	Id          uint64
-	Name        string
-	Email       string
-	Password    string
-	Lat         float32
-	Lon         float32
-	CreatedDate time.Time
-	UpdatedDate time.Time
-	DeletedDate *time.Time
+	Id           uint64
+	Name         string
+	Email        string
+	Password     string
+	Role         string
+	Lat          float32
+	Lon          float32
+	CreatedDate  time.Time
+	UpdatedDate  time.Time
+	DeletedDate  *time.Time
+	VerifiedDate *time.Time
 }
 
 type Users struct {
@@ -27,6 +29,19 @@ type ChangePassword struct {
 	NewPassword string
 }
 
+const (
+	UserRole  = "user"
+	AdminRole = "admin"
+)
+
 func (u User) GetUserId() uint64 {
 	return u.Id
 }
+
+func (u User) IsAdmin() bool {
+	return u.Role == AdminRole
+}
+
+func (u User) IsVerified() bool {
+	return u.VerifiedDate != nil
+}
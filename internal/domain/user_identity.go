@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+// UserIdentity links a user to an account at an external OpenID Connect provider.
+type UserIdentity struct {
+	Id          uint64
+	UserId      uint64
+	Provider    string
+	Subject     string
+	Email       string
+	CreatedDate time.Time
+}
+
+// OidcAuthRequest keeps the PKCE verifier and nonce of a started login until the callback.
+// BindingHash is the hash of the secret handed to the client that started the login,
+// the callback has to present it, so a leaked code and state can't be redeemed elsewhere.
+type OidcAuthRequest struct {
+	State        string
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	BindingHash  string
+	ExpiresDate  time.Time
+}
+
+// OidcLogin is a started login: where to send the user and the secret binding it to the client.
+type OidcLogin struct {
+	Url     string
+	Binding string
+}
+
+type OidcCallback struct {
+	Provider string
+	Code     string
+	State    string
+	Binding  string
+}
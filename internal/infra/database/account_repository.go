@@ -0,0 +1,149 @@
+package database
+
+import (
+	"boilerplate/internal/domain"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+type AccountRepository interface {
+	FindPurgeable(deletedBefore time.Time) ([]uint64, error)
+	Purge(userId uint64) ([]string, error)
+}
+
+type accountRepository struct {
+	sess db.Session
+}
+
+func NewAccountRepository(dbSession db.Session) AccountRepository {
+	return accountRepository{
+		sess: dbSession,
+	}
+}
+
+// FindPurgeable returns ids of the users deleted before the given date.
+func (r accountRepository) FindPurgeable(deletedBefore time.Time) ([]uint64, error) {
+	var data []user
+	err := r.sess.Collection(UsersTableName).
+		Find(db.Cond{"deleted_date <": deletedBefore}).
+		Select("id").
+		OrderBy("deleted_date").
+		All(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint64, len(data))
+	for i, u := range data {
+		ids[i] = u.Id
+	}
+	return ids, nil
+}
+
+// Purge hard-deletes a deleted user. Groups shared with other members are handed over to
+// one of them first, everything else referencing the user goes with it through the
+// cascading foreign keys, sessions have none and are removed explicitly. Returns the
+// paths of the removed photos, their files are not touched.
+func (r accountRepository) Purge(userId uint64) ([]string, error) {
+	var paths []string
+	err := r.sess.Tx(func(tx db.Session) error {
+		rows, err := tx.SQL().Query(`SELECT path FROM `+LocationPhotosTableName+`
+			WHERE user_id = ? OR location_id IN (SELECT id FROM `+LocationsTableName+` WHERE user_id = ?)`,
+			userId, userId)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var path string
+			err = rows.Scan(&path)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, path)
+		}
+		err = rows.Err()
+		if err != nil {
+			return err
+		}
+
+		err = r.handOverGroups(tx, userId)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Collection(SessionsTableName).Find(db.Cond{"user_id": userId}).Delete()
+		if err != nil {
+			return err
+		}
+
+		return tx.Collection(UsersTableName).Find(db.Cond{"id": userId, "deleted_date": db.IsNotNull()}).Delete()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// handOverGroups makes the member with the most permissions, the longest-standing one on
+// a tie, the owner of every group of the user. The new owner stops being a member, like
+// after an accepted transfer. Groups without other members are left to be deleted.
+func (r accountRepository) handOverGroups(tx db.Session, userId uint64) error {
+	var groups []group
+	err := tx.Collection(GroupsTableName).Find(db.Cond{"user_id": userId}).Select("id").All(&groups)
+	if err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		row, err := tx.SQL().QueryRow(`SELECT gm.user_id FROM `+GroupMembersTableName+` gm
+			JOIN `+GroupRolesTableName+` gr ON gr.id = gm.role_id
+			JOIN `+UsersTableName+` u ON u.id = gm.user_id
+			WHERE gm.group_id = ? AND gm.user_id <> ? AND gm.deleted_date IS NULL AND u.deleted_date IS NULL
+			ORDER BY cardinality(gr.permissions) DESC, gm.created_date, gm.id
+			LIMIT 1`,
+			g.Id, userId)
+		if err != nil {
+			return err
+		}
+		var ownerId uint64
+		err = row.Scan(&ownerId)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = updateOne(tx.SQL().
+			Update(GroupsTableName).
+			Set("user_id", ownerId, "updated_date", time.Now()).
+			Where(db.Cond{"id": g.Id, "user_id": userId}))
+		if err != nil {
+			return err
+		}
+
+		err = updateOne(tx.SQL().
+			Update(GroupMembersTableName).
+			Set("deleted_date", time.Now()).
+			Where(db.Cond{"user_id": ownerId, "group_id": g.Id, "deleted_date": nil}))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.SQL().
+			Update(GroupTransfersTableName).
+			Set("status", domain.TransferCancelled, "updated_date", time.Now()).
+			Where(db.Cond{"group_id": g.Id, "status": domain.TransferPending}).
+			Exec()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// IsUniqueViolation reports whether the error is a violation of the unique constraint.
+func IsUniqueViolation(err error, constraint string) bool {
+	return isViolation(err, uniqueViolation, constraint)
+}
+
+// IsForeignKeyViolation reports whether the error is a violation of the foreign key constraint.
+func IsForeignKeyViolation(err error, constraint string) bool {
+	return isViolation(err, foreignKeyViolation, constraint)
+}
+
+func isViolation(err error, code pq.ErrorCode, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code && pqErr.Constraint == constraint
+}
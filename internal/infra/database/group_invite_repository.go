@@ -0,0 +1,108 @@
+package database
+
+import (
+	"boilerplate/internal/domain"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+const (
+	GroupInvitesTableName = "group_invites"
+	// GroupInviteCodeKey keeps invite codes unique.
+	GroupInviteCodeKey = "group_invites_code_key"
+)
+
+type groupInvite struct {
+	Id          uint64     `db:"id,omitempty"`
+	GroupId     uint64     `db:"group_id"`
+	UserId      uint64     `db:"user_id"`
+	Code        string     `db:"code"`
+	MaxUses     uint64     `db:"max_uses"`
+	Uses        uint64     `db:"uses"`
+	ExpiresDate *time.Time `db:"expires_date"`
+	RevokedDate *time.Time `db:"revoked_date"`
+	CreatedDate time.Time  `db:"created_date,omitempty"`
+}
+
+type GroupInviteRepository interface {
+	Save(invite domain.GroupInvite) (domain.GroupInvite, error)
+	FindById(id uint64) (domain.GroupInvite, error)
+	FindByGroupId(groupId uint64) ([]domain.GroupInvite, error)
+	Revoke(id uint64) error
+}
+
+type groupInviteRepository struct {
+	coll db.Collection
+}
+
+func NewGroupInviteRepository(dbSession db.Session) GroupInviteRepository {
+	return groupInviteRepository{
+		coll: dbSession.Collection(GroupInvitesTableName),
+	}
+}
+
+func (r groupInviteRepository) Save(invite domain.GroupInvite) (domain.GroupInvite, error) {
+	i := r.mapDomainToModel(invite)
+	i.CreatedDate = time.Now()
+	err := r.coll.InsertReturning(&i)
+	if err != nil {
+		return domain.GroupInvite{}, err
+	}
+	return r.mapModelToDomain(i), nil
+}
+
+func (r groupInviteRepository) FindById(id uint64) (domain.GroupInvite, error) {
+	var i groupInvite
+	err := r.coll.Find(db.Cond{"id": id}).One(&i)
+	if err != nil {
+		return domain.GroupInvite{}, err
+	}
+	return r.mapModelToDomain(i), nil
+}
+
+func (r groupInviteRepository) FindByGroupId(groupId uint64) ([]domain.GroupInvite, error) {
+	var data []groupInvite
+	err := r.coll.Find(db.Cond{"group_id": groupId}).OrderBy("-created_date").All(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	invites := make([]domain.GroupInvite, len(data))
+	for i, invite := range data {
+		invites[i] = r.mapModelToDomain(invite)
+	}
+	return invites, nil
+}
+
+func (r groupInviteRepository) Revoke(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id, "revoked_date": nil}).Update(map[string]interface{}{"revoked_date": time.Now()})
+}
+
+func (r groupInviteRepository) mapDomainToModel(d domain.GroupInvite) groupInvite {
+	return groupInvite{
+		Id:          d.Id,
+		GroupId:     d.GroupId,
+		UserId:      d.UserId,
+		Code:        d.Code,
+		MaxUses:     d.MaxUses,
+		Uses:        d.Uses,
+		ExpiresDate: d.ExpiresDate,
+		RevokedDate: d.RevokedDate,
+		CreatedDate: d.CreatedDate,
+	}
+}
+
+func (r groupInviteRepository) mapModelToDomain(m groupInvite) domain.GroupInvite {
+	return domain.GroupInvite{
+		Id:          m.Id,
+		GroupId:     m.GroupId,
+		UserId:      m.UserId,
+		Code:        m.Code,
+		MaxUses:     m.MaxUses,
+		Uses:        m.Uses,
+		ExpiresDate: m.ExpiresDate,
+		RevokedDate: m.RevokedDate,
+		CreatedDate: m.CreatedDate,
+	}
+}
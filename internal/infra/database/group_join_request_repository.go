@@ -0,0 +1,185 @@
+package database
+
+import (
+	"boilerplate/internal/domain"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+const GroupJoinRequestsTableName = "group_join_requests"
+
+type groupJoinRequest struct {
+	Id          uint64     `db:"id,omitempty"`
+	GroupId     uint64     `db:"group_id"`
+	UserId      uint64     `db:"user_id"`
+	Status      string     `db:"status"`
+	DecidedBy   *uint64    `db:"decided_by"`
+	DecidedDate *time.Time `db:"decided_date"`
+	CreatedDate time.Time  `db:"created_date,omitempty"`
+	UpdatedDate time.Time  `db:"updated_date,omitempty"`
+}
+
+type GroupJoinRequestRepository interface {
+	FindById(id uint64) (domain.GroupJoinRequest, error)
+	FindPendingByGroupId(p domain.Pagination, groupId uint64) (domain.GroupJoinRequests, error)
+	FindPendingByUserId(userId uint64) ([]domain.GroupJoinRequest, error)
+	Approve(id uint64, moderatorId uint64) (domain.GroupMember, error)
+	Reject(id uint64, moderatorId uint64) error
+	Cancel(id uint64) error
+}
+
+type groupJoinRequestRepository struct {
+	coll db.Collection
+	sess db.Session
+}
+
+func NewGroupJoinRequestRepository(dbSession db.Session) GroupJoinRequestRepository {
+	return groupJoinRequestRepository{
+		coll: dbSession.Collection(GroupJoinRequestsTableName),
+		sess: dbSession,
+	}
+}
+
+func (r groupJoinRequestRepository) FindById(id uint64) (domain.GroupJoinRequest, error) {
+	var jr groupJoinRequest
+	err := r.coll.Find(db.Cond{"id": id}).One(&jr)
+	if err != nil {
+		return domain.GroupJoinRequest{}, err
+	}
+	return r.mapModelToDomain(jr), nil
+}
+
+func (r groupJoinRequestRepository) FindPendingByGroupId(p domain.Pagination, groupId uint64) (domain.GroupJoinRequests, error) {
+	var data []groupJoinRequest
+	query := r.coll.Find(db.Cond{"group_id": groupId, "status": domain.JoinRequestPending}).OrderBy("created_date")
+	res := query.Paginate(uint(p.CountPerPage))
+	err := res.Page(uint(p.Page)).All(&data)
+	if err != nil {
+		return domain.GroupJoinRequests{}, err
+	}
+
+	requests := r.mapModelToDomainPagination(data)
+
+	totalCount, err := res.TotalEntries()
+	if err != nil {
+		return domain.GroupJoinRequests{}, err
+	}
+
+	requests.Total = totalCount
+	requests.Pages = uint(math.Ceil(float64(requests.Total) / float64(p.CountPerPage)))
+
+	return requests, nil
+}
+
+func (r groupJoinRequestRepository) FindPendingByUserId(userId uint64) ([]domain.GroupJoinRequest, error) {
+	var data []groupJoinRequest
+	err := r.coll.Find(db.Cond{"user_id": userId, "status": domain.JoinRequestPending}).OrderBy("-created_date").All(&data)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainPagination(data).Items, nil
+}
+
+// Approve turns a pending request into a casual membership. Returns db.ErrNoMoreRows
+// when the request is not pending anymore or the group has been deleted.
+func (r groupJoinRequestRepository) Approve(id uint64, moderatorId uint64) (domain.GroupMember, error) {
+	var grpMember groupMember
+	err := r.sess.Tx(func(tx db.Session) error {
+		var jr groupJoinRequest
+		err := tx.Collection(GroupJoinRequestsTableName).Find(db.Cond{"id": id}).One(&jr)
+		if err != nil {
+			return err
+		}
+
+		// the lock keeps the group from being deleted until the member is in
+		row, err := tx.SQL().QueryRow(`SELECT id FROM `+GroupsTableName+` WHERE id = ? AND deleted_date IS NULL FOR SHARE`, jr.GroupId)
+		if err != nil {
+			return err
+		}
+		err = row.Scan(&jr.GroupId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return db.ErrNoMoreRows
+			}
+			return err
+		}
+
+		err = r.decide(tx, id, moderatorId, domain.JoinRequestApproved)
+		if err != nil {
+			return err
+		}
+
+		members := tx.Collection(GroupMembersTableName)
+		err = members.Find(db.Cond{"user_id": jr.UserId, "group_id": jr.GroupId, "deleted_date": nil}).One(&grpMember)
+		if err == nil {
+			return nil
+		}
+		if err != db.ErrNoMoreRows {
+			return err
+		}
+
+		roleId, err := findDefaultRoleId(tx, jr.GroupId)
+		if err != nil {
+			return err
+		}
+
+		grpMember = groupMember{
+			GroupId:     jr.GroupId,
+			UserId:      jr.UserId,
+			RoleId:      roleId,
+			CreatedDate: time.Now(),
+			UpdatedDate: time.Now(),
+		}
+		return members.InsertReturning(&grpMember)
+	})
+	if err != nil {
+		return domain.GroupMember{}, err
+	}
+	return groupMemberRepository{}.mapModelToDomain(grpMember), nil
+}
+
+// Reject declines a pending request. Returns db.ErrNoMoreRows when it is not pending anymore.
+func (r groupJoinRequestRepository) Reject(id uint64, moderatorId uint64) error {
+	return r.decide(r.sess, id, moderatorId, domain.JoinRequestRejected)
+}
+
+// Cancel withdraws a pending request. Returns db.ErrNoMoreRows when it is not pending anymore.
+func (r groupJoinRequestRepository) Cancel(id uint64) error {
+	return r.updatePending(r.sess, id, "status", domain.JoinRequestCancelled, "updated_date", time.Now())
+}
+
+func (r groupJoinRequestRepository) decide(sess db.Session, id uint64, moderatorId uint64, status string) error {
+	return r.updatePending(sess, id, "status", status, "decided_by", moderatorId, "decided_date", time.Now(), "updated_date", time.Now())
+}
+
+func (r groupJoinRequestRepository) updatePending(sess db.Session, id uint64, set ...interface{}) error {
+	return updateOne(sess.SQL().
+		Update(GroupJoinRequestsTableName).
+		Set(set...).
+		Where(db.Cond{"id": id, "status": domain.JoinRequestPending}))
+}
+
+func (r groupJoinRequestRepository) mapModelToDomain(m groupJoinRequest) domain.GroupJoinRequest {
+	return domain.GroupJoinRequest{
+		Id:          m.Id,
+		GroupId:     m.GroupId,
+		UserId:      m.UserId,
+		Status:      m.Status,
+		DecidedBy:   m.DecidedBy,
+		DecidedDate: m.DecidedDate,
+		CreatedDate: m.CreatedDate,
+		UpdatedDate: m.UpdatedDate,
+	}
+}
+
+func (r groupJoinRequestRepository) mapModelToDomainPagination(requests []groupJoinRequest) domain.GroupJoinRequests {
+	newRequests := make([]domain.GroupJoinRequest, len(requests))
+	for i, request := range requests {
+		newRequests[i] = r.mapModelToDomain(request)
+	}
+	return domain.GroupJoinRequests{Items: newRequests}
+}
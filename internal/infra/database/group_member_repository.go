@@ -2,6 +2,8 @@ package database
 
 import (
 	"boilerplate/internal/domain"
+	"database/sql"
+	"errors"
 	"fmt"
 	"math"
 	"time"
@@ -15,62 +17,121 @@ type groupMember struct {
 	Id          uint64     `db:"id,omitempty"`
 	UserId      uint64     `db:"user_id"`
 	GroupId     uint64     `db:"group_id"`
-	AccessLevel string     `db:"access_level"`
+	RoleId      uint64     `db:"role_id"`
 	CreatedDate time.Time  `db:"created_date,omitempty"`
 	UpdatedDate time.Time  `db:"updated_date,omitempty"`
 	DeletedDate *time.Time `db:"deleted_date,omitempty"`
 }
 
 type GroupMemberRepository interface {
-	AddGroupMember(accessCode string, userId uint64, gr GroupRepository) (domain.GroupMember, error)
-	ChangeAccessLevel(groupMember domain.GroupMember, newAccessLevel string) (domain.GroupMember, error)
+	AddGroupMember(code string, userId uint64) (domain.GroupMember, domain.GroupJoinRequest, error)
+	ChangeRole(groupMember domain.GroupMember, roleId uint64) (domain.GroupMember, error)
 	GetMembersList(p domain.Pagination, groupId uint64) (domain.GroupMembers, error)
 	FindById(id uint64) (domain.GroupMember, error)
 	DeleteGroupMember(id uint64) error
+	Leave(userId uint64, groupId uint64) error
 	FindMember(userId uint64, groupId uint64) (domain.GroupMember, error)
+	FindByUserId(userId uint64) (domain.GroupMembers, error)
 	FindMembersByArea(p domain.Pagination, groupId uint64, points map[string]map[string]float32, ur UserRepository) (domain.GroupMembers, error)
 }
 
 type groupMemberRepository struct {
 	coll db.Collection
+	sess db.Session
 }
 
 func NewGroupMemberRepository(dbSession db.Session) groupMemberRepository {
 	return groupMemberRepository{
 		coll: dbSession.Collection(GroupMembersTableName),
+		sess: dbSession,
 	}
 }
 
-func (r groupMemberRepository) AddGroupMember(accessCode string, userId uint64, gr GroupRepository) (domain.GroupMember, error) {
-	grp, err := gr.GetGroupByAccessCode(accessCode)
+// AddGroupMember joins the user to the group of an active invite and counts the use.
+// When the group requires approval a pending join request is created instead and the
+// returned member is empty. Returns db.ErrNoMoreRows when the invite is unknown, used up,
+// expired or revoked, the use is not counted when the user can't join.
+func (r groupMemberRepository) AddGroupMember(code string, userId uint64) (domain.GroupMember, domain.GroupJoinRequest, error) {
+	var (
+		grpMember   groupMember
+		joinRequest groupJoinRequest
+	)
+	err := r.sess.Tx(func(tx db.Session) error {
+		row, err := tx.SQL().QueryRow(`UPDATE `+GroupInvitesTableName+` SET uses = uses + 1
+			WHERE code = ? AND revoked_date IS NULL AND (expires_date IS NULL OR expires_date > ?)
+			AND (max_uses = 0 OR uses < max_uses)
+			RETURNING group_id`, code, time.Now())
+		if err != nil {
+			return err
+		}
+		var groupId uint64
+		err = row.Scan(&groupId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return db.ErrNoMoreRows
+			}
+			return err
+		}
+
+		var grp group
+		err = tx.Collection(GroupsTableName).Find(db.Cond{"id": groupId, "deleted_date": nil}).One(&grp)
+		if err != nil {
+			return err
+		}
+		if grp.UserId == userId {
+			return fmt.Errorf("creator can`t be the member of the group")
+		}
+
+		members := tx.Collection(GroupMembersTableName)
+		exists, err := members.Find(db.Cond{"user_id": userId, "group_id": groupId, "deleted_date": nil}).Exists()
+		if err != nil || exists {
+			return fmt.Errorf("current user already belong to this group")
+		}
+
+		if grp.RequiresApproval {
+			requests := tx.Collection(GroupJoinRequestsTableName)
+			exists, err = requests.Find(db.Cond{"user_id": userId, "group_id": groupId, "status": domain.JoinRequestPending}).Exists()
+			if err != nil || exists {
+				return fmt.Errorf("join request to this group is already pending")
+			}
+
+			joinRequest = groupJoinRequest{
+				GroupId:     groupId,
+				UserId:      userId,
+				Status:      domain.JoinRequestPending,
+				CreatedDate: time.Now(),
+				UpdatedDate: time.Now(),
+			}
+			return requests.InsertReturning(&joinRequest)
+		}
+
+		roleId, err := findDefaultRoleId(tx, groupId)
+		if err != nil {
+			return err
+		}
+
+		grpMember = groupMember{
+			GroupId:     groupId,
+			UserId:      userId,
+			RoleId:      roleId,
+			CreatedDate: time.Now(),
+			UpdatedDate: time.Now(),
+		}
+		return members.InsertReturning(&grpMember)
+	})
 	if err != nil {
-		return domain.GroupMember{}, err
-	}
-	if grp.UserId == userId {
-		return domain.GroupMember{}, fmt.Errorf("creator can`t be the member of the group")
+		return domain.GroupMember{}, domain.GroupJoinRequest{}, err
 	}
-	var grpMember groupMember
-	grpMember.GroupId = grp.Id
-	grpMember.UserId = userId
-	grpMember.AccessLevel = domain.CasualAccessLevel{}.GetRole()
-	grpMember.CreatedDate, grpMember.UpdatedDate = time.Now(), time.Now()
-	exists, err := r.coll.Find(db.Cond{"user_id": grpMember.UserId, "group_id": grpMember.GroupId}).Exists()
-	if err != nil || exists {
-		return domain.GroupMember{}, fmt.Errorf("current user already belong to this group")
-	}
-	err = r.coll.InsertReturning(&grpMember)
-	if err != nil {
-		return domain.GroupMember{}, err
+	if joinRequest.Id != 0 {
+		return domain.GroupMember{}, groupJoinRequestRepository{}.mapModelToDomain(joinRequest), nil
 	}
-	return r.mapModelToDomain(grpMember), nil
+	return r.mapModelToDomain(grpMember), domain.GroupJoinRequest{}, nil
 }
 
-func (r groupMemberRepository) ChangeAccessLevel(groupMember domain.GroupMember, newAccessLevel string) (domain.GroupMember, error) {
+func (r groupMemberRepository) ChangeRole(groupMember domain.GroupMember, roleId uint64) (domain.GroupMember, error) {
 	grpMember := r.mapDomainToModel(groupMember)
-	if !domain.GroupMember.AccessLevelExists(domain.GroupMember{}, newAccessLevel) {
-		return domain.GroupMember{}, fmt.Errorf("%s is not an access level", newAccessLevel)
-	}
-	grpMember.AccessLevel = newAccessLevel
+	grpMember.RoleId = roleId
+	grpMember.UpdatedDate = time.Now()
 	err := r.coll.Find(db.Cond{"id": grpMember.Id}).Update(&grpMember)
 	if err != nil {
 		return domain.GroupMember{}, err
@@ -80,7 +141,7 @@ func (r groupMemberRepository) ChangeAccessLevel(groupMember domain.GroupMember,
 
 func (r groupMemberRepository) GetMembersList(p domain.Pagination, groupId uint64) (domain.GroupMembers, error) {
 	var data []groupMember
-	query := r.coll.Find(db.Cond{"group_id": groupId})
+	query := r.coll.Find(db.Cond{"group_id": groupId, "deleted_date": nil})
 	res := query.Paginate(uint(p.CountPerPage))
 	err := res.Page(uint(p.Page)).All(&data)
 	if err != nil {
@@ -104,6 +165,27 @@ func (r groupMemberRepository) DeleteGroupMember(id uint64) error {
 	return r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": time.Now()})
 }
 
+// Leave removes the membership of the user and withdraws the ownership transfer offered
+// to them. Returns db.ErrNoMoreRows when the user is not a member of the group.
+func (r groupMemberRepository) Leave(userId uint64, groupId uint64) error {
+	return r.sess.Tx(func(tx db.Session) error {
+		err := updateOne(tx.SQL().
+			Update(GroupMembersTableName).
+			Set("deleted_date", time.Now()).
+			Where(db.Cond{"user_id": userId, "group_id": groupId, "deleted_date": nil}))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.SQL().
+			Update(GroupTransfersTableName).
+			Set("status", domain.TransferCancelled, "updated_date", time.Now()).
+			Where(db.Cond{"group_id": groupId, "to_user_id": userId, "status": domain.TransferPending}).
+			Exec()
+		return err
+	})
+}
+
 func (r groupMemberRepository) FindById(id uint64) (domain.GroupMember, error) {
 	var grpMember groupMember
 	err := r.coll.Find(db.Cond{"id": id}).One(&grpMember)
@@ -113,19 +195,41 @@ func (r groupMemberRepository) FindById(id uint64) (domain.GroupMember, error) {
 	return r.mapModelToDomain(grpMember), nil
 }
 
+func (r groupMemberRepository) FindByUserId(userId uint64) (domain.GroupMembers, error) {
+	var data []groupMember
+	err := r.coll.Find(db.Cond{"user_id": userId, "deleted_date": nil}).OrderBy("id").All(&data)
+	if err != nil {
+		return domain.GroupMembers{}, err
+	}
+
+	groupMembers := r.mapModelToDomainPagination(data)
+	groupMembers.Total = uint64(len(data))
+	return groupMembers, nil
+}
+
+// FindMember returns the current membership of the user together with its role.
 func (r groupMemberRepository) FindMember(userId uint64, groupId uint64) (domain.GroupMember, error) {
 	var grpMember groupMember
-	err := r.coll.Find(db.Cond{"user_id": userId, "group_id": groupId}).One(&grpMember)
+	err := r.coll.Find(db.Cond{"user_id": userId, "group_id": groupId, "deleted_date": nil}).One(&grpMember)
 	if err != nil {
 		return domain.GroupMember{}, err
 	}
-	return r.mapModelToDomain(grpMember), nil
+
+	var role groupRole
+	err = r.sess.Collection(GroupRolesTableName).Find(db.Cond{"id": grpMember.RoleId}).One(&role)
+	if err != nil {
+		return domain.GroupMember{}, err
+	}
+
+	member := r.mapModelToDomain(grpMember)
+	member.Role = groupRoleRepository{}.mapModelToDomain(role)
+	return member, nil
 }
 
 func (r groupMemberRepository) FindMembersByArea(p domain.Pagination, groupId uint64, points map[string]map[string]float32, ur UserRepository) (domain.GroupMembers, error) {
 	var data []groupMember
 	usersId := ur.GetUsersIdByArea(points)
-	query := r.coll.Find(db.Cond{"user_id IN": usersId, "group_id": groupId})
+	query := r.coll.Find(db.Cond{"user_id IN": usersId, "group_id": groupId, "deleted_date": nil})
 	res := query.Paginate(uint(p.CountPerPage))
 	err := res.Page(uint(p.Page)).All(&data)
 	if err != nil {
@@ -150,7 +254,7 @@ func (r groupMemberRepository) mapDomainToModel(d domain.GroupMember) groupMembe
 		Id:          d.Id,
 		UserId:      d.UserId,
 		GroupId:     d.GroupId,
-		AccessLevel: d.AccessLevel,
+		RoleId:      d.RoleId,
 		CreatedDate: d.CreatedDate,
 		UpdatedDate: d.UpdatedDate,
 		DeletedDate: d.DeletedDate,
@@ -162,7 +266,7 @@ func (r groupMemberRepository) mapModelToDomain(m groupMember) domain.GroupMembe
 		Id:          m.Id,
 		UserId:      m.UserId,
 		GroupId:     m.GroupId,
-		AccessLevel: m.AccessLevel,
+		RoleId:      m.RoleId,
 		CreatedDate: m.CreatedDate,
 		UpdatedDate: m.UpdatedDate,
 		DeletedDate: m.DeletedDate,
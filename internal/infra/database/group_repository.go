@@ -3,22 +3,34 @@ package database
 import (
 	"boilerplate/internal/domain"
 	"math"
+	"strings"
 	"time"
 
 	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
 )
 
 const GroupsTableName = "groups"
 
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type group struct {
-	Id          uint64     `db:"id,omitempty"`
-	Title       string     `db:"title"`
-	Description string     `db:"description"`
-	UserId      uint64     `db:"user_id"`
-	AccessCode  string     `db:"access_code"`
-	CreatedDate time.Time  `db:"created_date,omitempty"`
-	UpdatedDate time.Time  `db:"updated_date,omitempty"`
-	DeletedDate *time.Time `db:"deleted_date,omitempty"`
+	Id               uint64     `db:"id,omitempty"`
+	Title            string     `db:"title"`
+	Description      string     `db:"description"`
+	UserId           uint64     `db:"user_id"`
+	RequiresApproval bool       `db:"requires_approval"`
+	Discoverable     bool       `db:"discoverable"`
+	CreatedDate      time.Time  `db:"created_date,omitempty"`
+	UpdatedDate      time.Time  `db:"updated_date,omitempty"`
+	DeletedDate      *time.Time `db:"deleted_date,omitempty"`
+}
+
+type userGroup struct {
+	group       `db:",inline"`
+	RoleId      *uint64                `db:"role_id"`
+	RoleName    *string                `db:"role_name"`
+	Permissions postgresql.StringArray `db:"permissions"`
 }
 
 type GroupRepository interface {
@@ -26,25 +38,34 @@ type GroupRepository interface {
 	Update(group domain.Group) (domain.Group, error)
 	Delete(id uint64) error
 	FindById(id uint64) (domain.Group, error)
-	GetList(p domain.Pagination) (domain.Groups, error)
-	GetAccessCode(group domain.Group) string
-	GetGroupByAccessCode(accessCode string) (domain.Group, error)
+	FindByUserId(userId uint64) (domain.Groups, error)
+	FindByMember(p domain.Pagination, userId uint64) (domain.UserGroups, error)
+	Search(p domain.Pagination, title string) (domain.Groups, error)
 }
 
 type groupRepository struct {
 	coll db.Collection
+	sess db.Session
 }
 
 func NewGroupRepository(dbSession db.Session) groupRepository {
 	return groupRepository{
 		coll: dbSession.Collection(GroupsTableName),
+		sess: dbSession,
 	}
 }
 
+// Save creates the group together with its default roles.
 func (r groupRepository) Save(group domain.Group) (domain.Group, error) {
 	grp := r.mapDomainToModel(group)
 	grp.CreatedDate, grp.UpdatedDate = time.Now(), time.Now()
-	err := r.coll.InsertReturning(&grp)
+	err := r.sess.Tx(func(tx db.Session) error {
+		err := tx.Collection(GroupsTableName).InsertReturning(&grp)
+		if err != nil {
+			return err
+		}
+		return saveDefaultGroupRoles(tx, grp.Id)
+	})
 	if err != nil {
 		return domain.Group{}, err
 	}
@@ -67,27 +88,63 @@ func (r groupRepository) Delete(id uint64) error {
 
 func (r groupRepository) FindById(id uint64) (domain.Group, error) {
 	var grp group
-	err := r.coll.Find(db.Cond{"id": id}).One(&grp)
+	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).One(&grp)
 	if err != nil {
 		return domain.Group{}, err
 	}
 	return r.mapModelToDomain(grp), nil
 }
 
-func (r groupRepository) GetList(p domain.Pagination) (domain.Groups, error) {
+func (r groupRepository) FindByUserId(userId uint64) (domain.Groups, error) {
 	var data []group
-	query := r.coll.Find(db.Cond{})
-	res := query.Paginate(uint(p.CountPerPage))
-	err := res.Page(uint(p.Page)).All(&data)
+	err := r.coll.Find(db.Cond{"user_id": userId, "deleted_date": nil}).OrderBy("id").All(&data)
 	if err != nil {
 		return domain.Groups{}, err
 	}
 
 	groups := r.mapModelToDomainPagination(data)
+	groups.Total = uint64(len(data))
+	return groups, nil
+}
+
+// FindByMember lists the groups the user owns or is a member of with the user's role,
+// leaving out deleted groups and memberships. The owner gets domain.OwnerRole.
+func (r groupRepository) FindByMember(p domain.Pagination, userId uint64) (domain.UserGroups, error) {
+	var data []userGroup
+	query := r.sess.SQL().
+		Select("g.*", "gr.id AS role_id", "gr.name AS role_name", "gr.permissions").
+		From(GroupsTableName+" AS g").
+		LeftJoin(GroupMembersTableName+" AS gm").
+		On("gm.group_id = g.id AND gm.user_id = ? AND gm.deleted_date IS NULL", userId).
+		LeftJoin(GroupRolesTableName+" AS gr").
+		On("gr.id = gm.role_id").
+		Where("g.deleted_date IS NULL AND (g.user_id = ? OR gm.id IS NOT NULL)", userId).
+		OrderBy("g.id")
+	res := query.Paginate(uint(p.CountPerPage))
+	err := res.Page(uint(p.Page)).All(&data)
+	if err != nil {
+		return domain.UserGroups{}, err
+	}
+
+	items := make([]domain.UserGroup, len(data))
+	for i := range data {
+		items[i] = domain.UserGroup{Group: r.mapModelToDomain(data[i].group)}
+		if data[i].UserId == userId || data[i].RoleId == nil {
+			items[i].Role = domain.OwnerRole(data[i].Id)
+			continue
+		}
+		items[i].Role = groupRoleRepository{}.mapModelToDomain(groupRole{
+			Id:          *data[i].RoleId,
+			GroupId:     data[i].Id,
+			Name:        *data[i].RoleName,
+			Permissions: data[i].Permissions,
+		})
+	}
+	groups := domain.UserGroups{Items: items}
 
 	totalCount, err := res.TotalEntries()
 	if err != nil {
-		return domain.Groups{}, err
+		return domain.UserGroups{}, err
 	}
 
 	groups.Total = totalCount
@@ -96,42 +153,57 @@ func (r groupRepository) GetList(p domain.Pagination) (domain.Groups, error) {
 	return groups, nil
 }
 
-func (r groupRepository) GetAccessCode(group domain.Group) string {
-	return group.AcessCode
-}
+// Search finds discoverable groups whose title contains the text, ignoring case.
+func (r groupRepository) Search(p domain.Pagination, title string) (domain.Groups, error) {
+	var data []group
+	query := r.coll.Find(db.And(
+		db.Cond{"discoverable": true, "deleted_date": nil},
+		db.Raw(`title ILIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(title)+"%"),
+	)).OrderBy("title", "id")
+	res := query.Paginate(uint(p.CountPerPage))
+	err := res.Page(uint(p.Page)).All(&data)
+	if err != nil {
+		return domain.Groups{}, err
+	}
 
-func (r groupRepository) GetGroupByAccessCode(accessCode string) (domain.Group, error) {
-	var grp group
-	err := r.coll.Find(db.Cond{"access_code": accessCode}).One(&grp)
+	groups := r.mapModelToDomainPagination(data)
+
+	totalCount, err := res.TotalEntries()
 	if err != nil {
-		return domain.Group{}, err
+		return domain.Groups{}, err
 	}
-	return r.mapModelToDomain(grp), nil
+
+	groups.Total = totalCount
+	groups.Pages = uint(math.Ceil(float64(groups.Total) / float64(p.CountPerPage)))
+
+	return groups, nil
 }
 
 func (r groupRepository) mapDomainToModel(d domain.Group) group {
 	return group{
-		Id:          d.Id,
-		UserId:      d.UserId,
-		Title:       d.Title,
-		Description: d.Description,
-		AccessCode:  d.AcessCode,
-		CreatedDate: d.CreatedDate,
-		UpdatedDate: d.UpdatedDate,
-		DeletedDate: d.DeletedDate,
+		Id:               d.Id,
+		UserId:           d.UserId,
+		Title:            d.Title,
+		Description:      d.Description,
+		RequiresApproval: d.RequiresApproval,
+		Discoverable:     d.Discoverable,
+		CreatedDate:      d.CreatedDate,
+		UpdatedDate:      d.UpdatedDate,
+		DeletedDate:      d.DeletedDate,
 	}
 }
 
 func (r groupRepository) mapModelToDomain(m group) domain.Group {
 	return domain.Group{
-		Id:          m.Id,
-		UserId:      m.UserId,
-		Title:       m.Title,
-		Description: m.Description,
-		AcessCode:   m.AccessCode,
-		CreatedDate: m.CreatedDate,
-		UpdatedDate: m.UpdatedDate,
-		DeletedDate: m.DeletedDate,
+		Id:               m.Id,
+		UserId:           m.UserId,
+		Title:            m.Title,
+		Description:      m.Description,
+		RequiresApproval: m.RequiresApproval,
+		Discoverable:     m.Discoverable,
+		CreatedDate:      m.CreatedDate,
+		UpdatedDate:      m.UpdatedDate,
+		DeletedDate:      m.DeletedDate,
 	}
 }
 
@@ -0,0 +1,238 @@
+package database
+
+import (
+	"boilerplate/internal/domain"
+	"errors"
+	"time"
+
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
+)
+
+const (
+	GroupRolesTableName = "group_roles"
+	// GroupRoleNameKey keeps role names unique within a group.
+	GroupRoleNameKey = "group_roles_name_key"
+	// groupMemberRoleForeignKey stops a role current members have from being deleted.
+	groupMemberRoleForeignKey = "group_members_role_id_fkey"
+)
+
+var ErrGroupRoleInUse = errors.New("group role is assigned to current members")
+
+type groupRole struct {
+	Id          uint64                 `db:"id,omitempty"`
+	GroupId     uint64                 `db:"group_id"`
+	Name        string                 `db:"name"`
+	Permissions postgresql.StringArray `db:"permissions"`
+	IsDefault   bool                   `db:"is_default"`
+	CreatedDate time.Time              `db:"created_date,omitempty"`
+	UpdatedDate time.Time              `db:"updated_date,omitempty"`
+}
+
+type GroupRoleRepository interface {
+	Save(role domain.GroupRole) (domain.GroupRole, error)
+	Update(role domain.GroupRole) (domain.GroupRole, error)
+	Delete(id uint64) error
+	FindById(id uint64) (domain.GroupRole, error)
+	FindByGroupId(groupId uint64) ([]domain.GroupRole, error)
+}
+
+type groupRoleRepository struct {
+	coll db.Collection
+	sess db.Session
+}
+
+func NewGroupRoleRepository(dbSession db.Session) GroupRoleRepository {
+	return groupRoleRepository{
+		coll: dbSession.Collection(GroupRolesTableName),
+		sess: dbSession,
+	}
+}
+
+// Save creates the role, making it the default one takes the flag from the previous default role.
+func (r groupRoleRepository) Save(role domain.GroupRole) (domain.GroupRole, error) {
+	gr := r.mapDomainToModel(role)
+	gr.CreatedDate, gr.UpdatedDate = time.Now(), time.Now()
+	err := r.sess.Tx(func(tx db.Session) error {
+		if gr.IsDefault {
+			err := r.unsetDefault(tx, gr.GroupId)
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Collection(GroupRolesTableName).InsertReturning(&gr)
+	})
+	if err != nil {
+		return domain.GroupRole{}, err
+	}
+	return r.mapModelToDomain(gr), nil
+}
+
+// Update changes the role, making it the default one takes the flag from the previous default role.
+func (r groupRoleRepository) Update(role domain.GroupRole) (domain.GroupRole, error) {
+	gr := r.mapDomainToModel(role)
+	gr.UpdatedDate = time.Now()
+	err := r.sess.Tx(func(tx db.Session) error {
+		if gr.IsDefault {
+			err := r.unsetDefault(tx, gr.GroupId)
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Collection(GroupRolesTableName).Find(db.Cond{"id": gr.Id}).Update(&gr)
+	})
+	if err != nil {
+		return domain.GroupRole{}, err
+	}
+	return r.mapModelToDomain(gr), nil
+}
+
+// Delete removes a role no current member has, returns ErrGroupRoleInUse otherwise.
+// Former members keep pointing at their last role, they are moved to the default role first.
+func (r groupRoleRepository) Delete(id uint64) error {
+	err := r.sess.Tx(func(tx db.Session) error {
+		var gr groupRole
+		err := tx.Collection(GroupRolesTableName).Find(db.Cond{"id": id}).One(&gr)
+		if err != nil {
+			return err
+		}
+		count, err := tx.Collection(GroupMembersTableName).Find(db.Cond{"role_id": id, "deleted_date": nil}).Count()
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrGroupRoleInUse
+		}
+
+		defaultRoleId, err := findDefaultRoleId(tx, gr.GroupId)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.SQL().
+			Update(GroupMembersTableName).
+			Set("role_id", defaultRoleId).
+			Where(db.Cond{"role_id": id, "deleted_date": db.IsNotNull()}).
+			Exec()
+		if err != nil {
+			return err
+		}
+
+		return tx.Collection(GroupRolesTableName).Find(db.Cond{"id": id}).Delete()
+	})
+	// a member given the role after the count still holds the foreign key
+	if IsForeignKeyViolation(err, groupMemberRoleForeignKey) {
+		return ErrGroupRoleInUse
+	}
+	return err
+}
+
+func (r groupRoleRepository) FindById(id uint64) (domain.GroupRole, error) {
+	var gr groupRole
+	err := r.coll.Find(db.Cond{"id": id}).One(&gr)
+	if err != nil {
+		return domain.GroupRole{}, err
+	}
+	return r.mapModelToDomain(gr), nil
+}
+
+func (r groupRoleRepository) FindByGroupId(groupId uint64) ([]domain.GroupRole, error) {
+	var data []groupRole
+	err := r.coll.Find(db.Cond{"group_id": groupId}).OrderBy("id").All(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]domain.GroupRole, len(data))
+	for i, gr := range data {
+		roles[i] = r.mapModelToDomain(gr)
+	}
+	return roles, nil
+}
+
+func (r groupRoleRepository) unsetDefault(tx db.Session, groupId uint64) error {
+	_, err := tx.SQL().
+		Update(GroupRolesTableName).
+		Set("is_default", false, "updated_date", time.Now()).
+		Where(db.Cond{"group_id": groupId, "is_default": true}).
+		Exec()
+	return err
+}
+
+// saveDefaultGroupRoles creates the roles every new group starts with.
+func saveDefaultGroupRoles(tx db.Session, groupId uint64) error {
+	coll := tx.Collection(GroupRolesTableName)
+	for _, role := range domain.DefaultGroupRoles(groupId) {
+		gr := groupRoleRepository{}.mapDomainToModel(role)
+		gr.CreatedDate, gr.UpdatedDate = time.Now(), time.Now()
+		_, err := coll.Insert(gr)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findDefaultRoleId returns the role new members of the group get.
+func findDefaultRoleId(tx db.Session, groupId uint64) (uint64, error) {
+	var gr groupRole
+	err := tx.Collection(GroupRolesTableName).Find(db.Cond{"group_id": groupId, "is_default": true}).One(&gr)
+	if err != nil {
+		return 0, err
+	}
+	return gr.Id, nil
+}
+
+// findFullRoleId returns the first role of the group with every permission,
+// falling back to the default role when there is none.
+func findFullRoleId(tx db.Session, groupId uint64) (uint64, error) {
+	all := make([]string, len(domain.Permissions))
+	for i, p := range domain.Permissions {
+		all[i] = string(p)
+	}
+
+	var gr groupRole
+	err := tx.Collection(GroupRolesTableName).
+		Find(db.And(db.Cond{"group_id": groupId}, db.Raw("permissions @> ?", postgresql.StringArray(all)))).
+		OrderBy("id").
+		One(&gr)
+	if err == db.ErrNoMoreRows {
+		return findDefaultRoleId(tx, groupId)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return gr.Id, nil
+}
+
+func (r groupRoleRepository) mapDomainToModel(d domain.GroupRole) groupRole {
+	permissions := make(postgresql.StringArray, len(d.Permissions))
+	for i, p := range d.Permissions {
+		permissions[i] = string(p)
+	}
+	return groupRole{
+		Id:          d.Id,
+		GroupId:     d.GroupId,
+		Name:        d.Name,
+		Permissions: permissions,
+		IsDefault:   d.IsDefault,
+		CreatedDate: d.CreatedDate,
+		UpdatedDate: d.UpdatedDate,
+	}
+}
+
+func (r groupRoleRepository) mapModelToDomain(m groupRole) domain.GroupRole {
+	permissions := make([]domain.Permission, len(m.Permissions))
+	for i, p := range m.Permissions {
+		permissions[i] = domain.Permission(p)
+	}
+	return domain.GroupRole{
+		Id:          m.Id,
+		GroupId:     m.GroupId,
+		Name:        m.Name,
+		Permissions: permissions,
+		IsDefault:   m.IsDefault,
+		CreatedDate: m.CreatedDate,
+		UpdatedDate: m.UpdatedDate,
+	}
+}
@@ -0,0 +1,224 @@
+package database
+
+import (
+	"boilerplate/internal/domain"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+const GroupTransfersTableName = "group_transfers"
+
+type groupTransfer struct {
+	Id          uint64     `db:"id,omitempty"`
+	GroupId     uint64     `db:"group_id"`
+	FromUserId  uint64     `db:"from_user_id"`
+	ToUserId    uint64     `db:"to_user_id"`
+	Status      string     `db:"status"`
+	DecidedDate *time.Time `db:"decided_date"`
+	CreatedDate time.Time  `db:"created_date,omitempty"`
+	UpdatedDate time.Time  `db:"updated_date,omitempty"`
+}
+
+type GroupTransferRepository interface {
+	Save(transfer domain.GroupTransfer) (domain.GroupTransfer, error)
+	FindById(id uint64) (domain.GroupTransfer, error)
+	FindPendingByGroupId(groupId uint64) (domain.GroupTransfer, error)
+	FindPendingByUserId(userId uint64) ([]domain.GroupTransfer, error)
+	Accept(id uint64) error
+	Decline(id uint64) error
+	CancelByGroupId(groupId uint64) error
+}
+
+type groupTransferRepository struct {
+	coll db.Collection
+	sess db.Session
+}
+
+func NewGroupTransferRepository(dbSession db.Session) GroupTransferRepository {
+	return groupTransferRepository{
+		coll: dbSession.Collection(GroupTransfersTableName),
+		sess: dbSession,
+	}
+}
+
+// Save nominates a member of the group, replacing the pending transfer if there is one.
+// Returns db.ErrNoMoreRows when the nominee is not a member of the group.
+func (r groupTransferRepository) Save(transfer domain.GroupTransfer) (domain.GroupTransfer, error) {
+	t := r.mapDomainToModel(transfer)
+	err := r.sess.Tx(func(tx db.Session) error {
+		exists, err := tx.Collection(GroupMembersTableName).
+			Find(db.Cond{"user_id": t.ToUserId, "group_id": t.GroupId, "deleted_date": nil}).
+			Exists()
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return db.ErrNoMoreRows
+		}
+
+		_, err = tx.SQL().
+			Update(GroupTransfersTableName).
+			Set("status", domain.TransferCancelled, "updated_date", time.Now()).
+			Where(db.Cond{"group_id": t.GroupId, "status": domain.TransferPending}).
+			Exec()
+		if err != nil {
+			return err
+		}
+
+		t.Status = domain.TransferPending
+		t.CreatedDate, t.UpdatedDate = time.Now(), time.Now()
+		return tx.Collection(GroupTransfersTableName).InsertReturning(&t)
+	})
+	if err != nil {
+		return domain.GroupTransfer{}, err
+	}
+	return r.mapModelToDomain(t), nil
+}
+
+func (r groupTransferRepository) FindById(id uint64) (domain.GroupTransfer, error) {
+	var t groupTransfer
+	err := r.coll.Find(db.Cond{"id": id}).One(&t)
+	if err != nil {
+		return domain.GroupTransfer{}, err
+	}
+	return r.mapModelToDomain(t), nil
+}
+
+func (r groupTransferRepository) FindPendingByGroupId(groupId uint64) (domain.GroupTransfer, error) {
+	var t groupTransfer
+	err := r.coll.Find(db.Cond{"group_id": groupId, "status": domain.TransferPending}).One(&t)
+	if err != nil {
+		return domain.GroupTransfer{}, err
+	}
+	return r.mapModelToDomain(t), nil
+}
+
+func (r groupTransferRepository) FindPendingByUserId(userId uint64) ([]domain.GroupTransfer, error) {
+	var data []groupTransfer
+	err := r.coll.Find(db.Cond{"to_user_id": userId, "status": domain.TransferPending}).OrderBy("-created_date").All(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := make([]domain.GroupTransfer, len(data))
+	for i, t := range data {
+		transfers[i] = r.mapModelToDomain(t)
+	}
+	return transfers, nil
+}
+
+// Accept makes the nominee the owner of the group. The nominee's membership is removed,
+// as the owner is not a member, and the previous owner stays in the group with a role
+// that has every permission, or the default role when the group has no such role.
+// Returns db.ErrNoMoreRows when the transfer is not pending anymore, the group changed
+// hands in the meantime or the nominee has left it.
+func (r groupTransferRepository) Accept(id uint64) error {
+	return r.sess.Tx(func(tx db.Session) error {
+		row, err := tx.SQL().QueryRow(`UPDATE `+GroupTransfersTableName+` SET status = ?, decided_date = ?, updated_date = ?
+			WHERE id = ? AND status = ?
+			RETURNING group_id, from_user_id, to_user_id`,
+			domain.TransferAccepted, time.Now(), time.Now(), id, domain.TransferPending)
+		if err != nil {
+			return err
+		}
+		var t groupTransfer
+		err = row.Scan(&t.GroupId, &t.FromUserId, &t.ToUserId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return db.ErrNoMoreRows
+			}
+			return err
+		}
+
+		err = updateOne(tx.SQL().
+			Update(GroupsTableName).
+			Set("user_id", t.ToUserId, "updated_date", time.Now()).
+			Where(db.Cond{"id": t.GroupId, "user_id": t.FromUserId, "deleted_date": nil}))
+		if err != nil {
+			return err
+		}
+
+		err = updateOne(tx.SQL().
+			Update(GroupMembersTableName).
+			Set("deleted_date", time.Now()).
+			Where(db.Cond{"user_id": t.ToUserId, "group_id": t.GroupId, "deleted_date": nil}))
+		if err != nil {
+			return err
+		}
+
+		roleId, err := findFullRoleId(tx, t.GroupId)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Collection(GroupMembersTableName).Insert(groupMember{
+			GroupId:     t.GroupId,
+			UserId:      t.FromUserId,
+			RoleId:      roleId,
+			CreatedDate: time.Now(),
+			UpdatedDate: time.Now(),
+		})
+		return err
+	})
+}
+
+// Decline refuses a pending transfer. Returns db.ErrNoMoreRows when it is not pending anymore.
+func (r groupTransferRepository) Decline(id uint64) error {
+	return updateOne(r.sess.SQL().
+		Update(GroupTransfersTableName).
+		Set("status", domain.TransferDeclined, "decided_date", time.Now(), "updated_date", time.Now()).
+		Where(db.Cond{"id": id, "status": domain.TransferPending}))
+}
+
+// CancelByGroupId withdraws the pending transfer of the group. Returns db.ErrNoMoreRows when there is none.
+func (r groupTransferRepository) CancelByGroupId(groupId uint64) error {
+	return updateOne(r.sess.SQL().
+		Update(GroupTransfersTableName).
+		Set("status", domain.TransferCancelled, "updated_date", time.Now()).
+		Where(db.Cond{"group_id": groupId, "status": domain.TransferPending}))
+}
+
+// updateOne runs the update and returns db.ErrNoMoreRows when it changed nothing.
+func updateOne(q db.Updater) error {
+	res, err := q.Exec()
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return db.ErrNoMoreRows
+	}
+	return nil
+}
+
+func (r groupTransferRepository) mapDomainToModel(d domain.GroupTransfer) groupTransfer {
+	return groupTransfer{
+		Id:          d.Id,
+		GroupId:     d.GroupId,
+		FromUserId:  d.FromUserId,
+		ToUserId:    d.ToUserId,
+		Status:      d.Status,
+		DecidedDate: d.DecidedDate,
+		CreatedDate: d.CreatedDate,
+		UpdatedDate: d.UpdatedDate,
+	}
+}
+
+func (r groupTransferRepository) mapModelToDomain(m groupTransfer) domain.GroupTransfer {
+	return domain.GroupTransfer{
+		Id:          m.Id,
+		GroupId:     m.GroupId,
+		FromUserId:  m.FromUserId,
+		ToUserId:    m.ToUserId,
+		Status:      m.Status,
+		DecidedDate: m.DecidedDate,
+		CreatedDate: m.CreatedDate,
+		UpdatedDate: m.UpdatedDate,
+	}
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"boilerplate/internal/domain"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+const LocationPhotosTableName = "location_photos"
+
+type locationPhoto struct {
+	Id          uint64    `db:"id,omitempty"`
+	LocationId  uint64    `db:"location_id"`
+	UserId      uint64    `db:"user_id"`
+	Path        string    `db:"path"`
+	ContentType string    `db:"content_type"`
+	Size        uint64    `db:"size"`
+	CreatedDate time.Time `db:"created_date,omitempty"`
+}
+
+type LocationPhotoRepository interface {
+	Save(photo domain.LocationPhoto) (domain.LocationPhoto, error)
+	FindById(id uint64) (domain.LocationPhoto, error)
+	FindByLocationId(locationId uint64) ([]domain.LocationPhoto, error)
+	Delete(id uint64) error
+}
+
+type locationPhotoRepository struct {
+	coll db.Collection
+}
+
+func NewLocationPhotoRepository(dbSession db.Session) locationPhotoRepository {
+	return locationPhotoRepository{
+		coll: dbSession.Collection(LocationPhotosTableName),
+	}
+}
+
+func (r locationPhotoRepository) Save(photo domain.LocationPhoto) (domain.LocationPhoto, error) {
+	p := r.mapDomainToModel(photo)
+	p.CreatedDate = time.Now()
+	err := r.coll.InsertReturning(&p)
+	if err != nil {
+		return domain.LocationPhoto{}, err
+	}
+	return r.mapModelToDomain(p), nil
+}
+
+func (r locationPhotoRepository) FindById(id uint64) (domain.LocationPhoto, error) {
+	var p locationPhoto
+	err := r.coll.Find(db.Cond{"id": id}).One(&p)
+	if err != nil {
+		return domain.LocationPhoto{}, err
+	}
+	return r.mapModelToDomain(p), nil
+}
+
+func (r locationPhotoRepository) FindByLocationId(locationId uint64) ([]domain.LocationPhoto, error) {
+	var data []locationPhoto
+	err := r.coll.Find(db.Cond{"location_id": locationId}).OrderBy("id").All(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	photos := make([]domain.LocationPhoto, len(data))
+	for i, p := range data {
+		photos[i] = r.mapModelToDomain(p)
+	}
+	return photos, nil
+}
+
+func (r locationPhotoRepository) Delete(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id}).Delete()
+}
+
+func (r locationPhotoRepository) mapDomainToModel(d domain.LocationPhoto) locationPhoto {
+	return locationPhoto{
+		Id:          d.Id,
+		LocationId:  d.LocationId,
+		UserId:      d.UserId,
+		Path:        d.Path,
+		ContentType: d.ContentType,
+		Size:        d.Size,
+		CreatedDate: d.CreatedDate,
+	}
+}
+
+func (r locationPhotoRepository) mapModelToDomain(m locationPhoto) domain.LocationPhoto {
+	return domain.LocationPhoto{
+		Id:          m.Id,
+		LocationId:  m.LocationId,
+		UserId:      m.UserId,
+		Path:        m.Path,
+		ContentType: m.ContentType,
+		Size:        m.Size,
+		CreatedDate: m.CreatedDate,
+	}
+}
@@ -2,74 +2,278 @@ package database
 
 import (
 	"boilerplate/internal/domain"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"math"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
 )
 
 const LocationsTableName = "locations"
+const LocationGroupsTableName = "location_groups"
+
+var ErrCapacityBelowOccupancy = errors.New("capacity is below the current occupancy")
 
 type location struct {
-	Id          uint64     `db:"id,omitempty"`
-	UserId      uint64     `db:"user_id,omitempty"`
-	Type        string     `db:"type"`
-	Address     string     `db:"address"`
-	Title       string     `db:"title"`
-	Description string     `db:"description"`
-	Lat         float64    `db:"lat"`
-	Lon         float64    `db:"lon"`
-	CreatedDate time.Time  `db:"created_date,omitempty"`
-	UpdatedDate time.Time  `db:"updated_date,omitempty"`
-	DeletedDate *time.Time `db:"deleted_date,omitempty"`
+	Id           uint64                 `db:"id,omitempty"`
+	UserId       uint64                 `db:"user_id,omitempty"`
+	Type         string                 `db:"type"`
+	Address      string                 `db:"address"`
+	Title        string                 `db:"title"`
+	Description  string                 `db:"description"`
+	Lat          float64                `db:"lat"`
+	Lon          float64                `db:"lon"`
+	Visibility   string                 `db:"visibility"`
+	Capacity     uint64                 `db:"capacity"`
+	Occupancy    uint64                 `db:"occupancy,omitempty"`
+	OpeningHours openingHours           `db:"opening_hours"`
+	Attributes   postgresql.StringArray `db:"attributes"`
+	CreatedDate  time.Time              `db:"created_date,omitempty"`
+	UpdatedDate  time.Time              `db:"updated_date,omitempty"`
+	DeletedDate  *time.Time             `db:"deleted_date,omitempty"`
+}
+
+type openingHours struct {
+	AlwaysOpen bool            `json:"always_open"`
+	Periods    []openingPeriod `json:"periods"`
+}
+
+type openingPeriod struct {
+	Weekday int    `json:"weekday"`
+	Opens   string `json:"opens"`
+	Closes  string `json:"closes"`
+}
+
+func (o openingHours) Value() (driver.Value, error) {
+	if o.Periods == nil {
+		o.Periods = []openingPeriod{}
+	}
+	return postgresql.JSONBValue(o)
+}
+
+func (o *openingHours) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(o, src)
 }
 
+// openNowExpr matches locations open at the given local time, takes
+// (weekday, time, time, previous weekday, time) arguments.
+const openNowExpr = `((opening_hours->>'always_open')::boolean OR EXISTS (
+	SELECT 1 FROM jsonb_array_elements(opening_hours->'periods') AS p
+	WHERE ((p->>'weekday')::int = ? AND p->>'opens' <= ? AND (p->>'closes' > ? OR p->>'closes' <= p->>'opens'))
+		OR ((p->>'weekday')::int = ? AND p->>'closes' <= p->>'opens' AND p->>'closes' > ?)
+))`
+
+type locationWithDistance struct {
+	location `db:",inline"`
+	Distance float64 `db:"distance"`
+}
+
+// earthRadius is the mean Earth radius in metres used by the haversine formula.
+const earthRadius = 6371000
+
+// visibleToExpr matches locations that are public, owned by the user or shared
+// with a group the user owns or belongs to. Takes the user id three times.
+const visibleToExpr = `(visibility = 'public' OR user_id = ? OR (visibility = 'groups' AND id IN (
+	SELECT lg.location_id FROM location_groups lg
+	WHERE lg.group_id IN (SELECT gm.group_id FROM group_members gm JOIN groups g ON g.id = gm.group_id
+			WHERE gm.user_id = ? AND gm.deleted_date IS NULL AND g.deleted_date IS NULL)
+		OR lg.group_id IN (SELECT g.id FROM groups g WHERE g.user_id = ? AND g.deleted_date IS NULL)
+)))`
+
+type locationGroup struct {
+	LocationId uint64 `db:"location_id"`
+	GroupId    uint64 `db:"group_id"`
+}
+
+// haversineExpr computes great-circle distance in metres between the row and
+// the point passed as (earthRadius, lat, lat, lon) arguments.
+const haversineExpr = `2 * ? * ASIN(SQRT(
+	POWER(SIN(RADIANS(lat::float8 - ?) / 2), 2) +
+	COS(RADIANS(?)) * COS(RADIANS(lat::float8)) * POWER(SIN(RADIANS(lon::float8 - ?) / 2), 2)
+))`
+
 type LocationRepository interface {
-	Save(sess domain.Location) (domain.Location, error)
-	Update(location domain.Location) (domain.Location, error)
-	Delete(id uint64) error
-	FindByArea(p domain.Pagination, points map[string]map[string]float32) (domain.Locations, error)
-	FindByUserId(p domain.Pagination, user_id uint64) (domain.Locations, error)
+	Save(location domain.Location, revision domain.LocationRevision) (domain.Location, error)
+	Update(location domain.Location, revision domain.LocationRevision) (domain.Location, error)
+	Delete(id uint64, revision domain.LocationRevision) error
+	FindByArea(p domain.Pagination, points map[string]map[string]float32, filter domain.LocationFilter) (domain.Locations, error)
+	FindByUserId(p domain.Pagination, user_id uint64, filter domain.LocationFilter) (domain.Locations, error)
 	FindById(id uint64) (domain.Location, error)
+	FindNearest(search domain.NearestSearch) (domain.Locations, error)
+	IsVisibleTo(locationId uint64, userId uint64) (bool, error)
+	SaveBatch(locations []domain.Location, revisions []domain.LocationRevision) ([]domain.Location, error)
+	EachByArea(points map[string]map[string]float32, filter domain.LocationFilter, fn func(domain.Location) error) error
+	EachByUserId(user_id uint64, filter domain.LocationFilter, fn func(domain.Location) error) error
+	Search(p domain.Pagination, search domain.LocationSearch) (domain.Locations, error)
 }
 
 type locationRepository struct {
 	coll db.Collection
+	sess db.Session
 }
 
 func NewLocationRepository(dbSession db.Session) locationRepository {
 	return locationRepository{
 		coll: dbSession.Collection(LocationsTableName),
+		sess: dbSession,
 	}
 }
 
-func (r locationRepository) Save(location domain.Location) (domain.Location, error) {
+// Save inserts the location together with its groups and the revision.
+func (r locationRepository) Save(location domain.Location, revision domain.LocationRevision) (domain.Location, error) {
 	loc := r.mapDomainToModel(location)
 	loc.CreatedDate, loc.UpdatedDate = time.Now(), time.Now()
-	err := r.coll.InsertReturning(&loc)
+	err := r.sess.Tx(func(tx db.Session) error {
+		err := tx.Collection(LocationsTableName).InsertReturning(&loc)
+		if err != nil {
+			return err
+		}
+		err = r.setGroups(tx, loc.Id, location.GroupIds)
+		if err != nil {
+			return err
+		}
+		revision.LocationId = loc.Id
+		return saveLocationRevision(tx, revision)
+	})
 	if err != nil {
 		return domain.Location{}, err
 	}
-	return r.mapModelToDomain(loc), nil
+	result := r.mapModelToDomain(loc)
+	result.GroupIds = location.GroupIds
+	return result, nil
 }
 
-func (r locationRepository) Update(location domain.Location) (domain.Location, error) {
+// Update changes the location, replaces its groups and saves the revision.
+// Returns db.ErrNoMoreRows when the location is deleted and ErrCapacityBelowOccupancy
+// when more people are checked in than the new capacity allows.
+func (r locationRepository) Update(location domain.Location, revision domain.LocationRevision) (domain.Location, error) {
 	loc := r.mapDomainToModel(location)
 	loc.UpdatedDate = time.Now()
-	err := r.coll.Find(db.Cond{"id": loc.Id}).Update(&loc)
+	// occupancy is changed only by check-in/check-out, zero value is omitted from the update
+	loc.Occupancy = 0
+	err := r.sess.Tx(func(tx db.Session) error {
+		// the lock holds check-ins back until the new capacity is in place
+		row, err := tx.SQL().QueryRow(`SELECT occupancy FROM `+LocationsTableName+` WHERE id = ? AND deleted_date IS NULL FOR UPDATE`, loc.Id)
+		if err != nil {
+			return err
+		}
+		var occupancy uint64
+		err = row.Scan(&occupancy)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return db.ErrNoMoreRows
+			}
+			return err
+		}
+		if loc.Capacity != 0 && loc.Capacity < occupancy {
+			return ErrCapacityBelowOccupancy
+		}
+
+		err = tx.Collection(LocationsTableName).Find(db.Cond{"id": loc.Id}).Update(&loc)
+		if err != nil {
+			return err
+		}
+		err = r.setGroups(tx, loc.Id, location.GroupIds)
+		if err != nil {
+			return err
+		}
+		revision.LocationId = loc.Id
+		return saveLocationRevision(tx, revision)
+	})
 	if err != nil {
 		return domain.Location{}, err
 	}
-	return r.mapModelToDomain(loc), nil
+	result := r.mapModelToDomain(loc)
+	result.Occupancy = location.Occupancy
+	result.GroupIds = location.GroupIds
+	return result, nil
 }
 
-func (r locationRepository) Delete(id uint64) error {
-	return r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": time.Now()})
+// SaveBatch inserts all locations with their groups and revisions in one transaction.
+func (r locationRepository) SaveBatch(locations []domain.Location, revisions []domain.LocationRevision) ([]domain.Location, error) {
+	result := make([]domain.Location, len(locations))
+	err := r.sess.Tx(func(tx db.Session) error {
+		coll := tx.Collection(LocationsTableName)
+		groupsColl := tx.Collection(LocationGroupsTableName)
+		for i, location := range locations {
+			loc := r.mapDomainToModel(location)
+			loc.CreatedDate, loc.UpdatedDate = time.Now(), time.Now()
+			err := coll.InsertReturning(&loc)
+			if err != nil {
+				return err
+			}
+			for _, groupId := range location.GroupIds {
+				_, err = groupsColl.Insert(locationGroup{LocationId: loc.Id, GroupId: groupId})
+				if err != nil {
+					return err
+				}
+			}
+			revision := revisions[i]
+			revision.LocationId = loc.Id
+			err = saveLocationRevision(tx, revision)
+			if err != nil {
+				return err
+			}
+			result[i] = r.mapModelToDomain(loc)
+			result[i].GroupIds = location.GroupIds
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
-func (r locationRepository) FindByArea(p domain.Pagination, points map[string]map[string]float32) (domain.Locations, error) {
+func (r locationRepository) setGroups(tx db.Session, locationId uint64, groupIds []uint64) error {
+	coll := tx.Collection(LocationGroupsTableName)
+	err := coll.Find(db.Cond{"location_id": locationId}).Delete()
+	if err != nil {
+		return err
+	}
+	for _, groupId := range groupIds {
+		_, err = coll.Insert(locationGroup{LocationId: locationId, GroupId: groupId})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsVisibleTo reports whether the location is not deleted and visible to the user,
+// by the same rules the listings use.
+func (r locationRepository) IsVisibleTo(locationId uint64, userId uint64) (bool, error) {
+	return r.coll.Find(db.And(
+		db.Cond{"id": locationId, "deleted_date": nil},
+		db.Raw(visibleToExpr, userId, userId, userId),
+	)).Exists()
+}
+
+// Delete marks the location as deleted and saves the revision. Returns db.ErrNoMoreRows
+// when it is already deleted.
+func (r locationRepository) Delete(id uint64, revision domain.LocationRevision) error {
+	return r.sess.Tx(func(tx db.Session) error {
+		err := updateOne(tx.SQL().
+			Update(LocationsTableName).
+			Set("deleted_date", time.Now()).
+			Where(db.Cond{"id": id, "deleted_date": nil}))
+		if err != nil {
+			return err
+		}
+		revision.LocationId = id
+		return saveLocationRevision(tx, revision)
+	})
+}
+
+func (r locationRepository) FindByArea(p domain.Pagination, points map[string]map[string]float32, filter domain.LocationFilter) (domain.Locations, error) {
 	var data []location
-	query := r.coll.Find(db.Cond{"lat >": points["UpperLeftPoint"]["lat"], "lat <": points["BottomRightPoint"]["lat"], "lon <": points["UpperLeftPoint"]["lon"], "lon >": points["BottomRightPoint"]["lon"]})
+	cond := db.Cond{"lat >": points["UpperLeftPoint"]["lat"], "lat <": points["BottomRightPoint"]["lat"], "lon <": points["UpperLeftPoint"]["lon"], "lon >": points["BottomRightPoint"]["lon"], "deleted_date": nil}
+	query := r.coll.Find(r.applyFilter(cond, filter))
 	res := query.Paginate(uint(p.CountPerPage))
 	err := res.Page(uint(p.Page)).All(&data)
 	if err != nil {
@@ -89,9 +293,9 @@ func (r locationRepository) FindByArea(p domain.Pagination, points map[string]ma
 	return locations, nil
 }
 
-func (r locationRepository) FindByUserId(p domain.Pagination, user_id uint64) (domain.Locations, error) {
+func (r locationRepository) FindByUserId(p domain.Pagination, user_id uint64, filter domain.LocationFilter) (domain.Locations, error) {
 	var data []location
-	query := r.coll.Find(db.Cond{"user_id": user_id})
+	query := r.coll.Find(r.applyFilter(db.Cond{"user_id": user_id, "deleted_date": nil}, filter))
 	res := query.Paginate(uint(p.CountPerPage))
 	err := res.Page(uint(p.Page)).All(&data)
 	if err != nil {
@@ -111,45 +315,211 @@ func (r locationRepository) FindByUserId(p domain.Pagination, user_id uint64) (d
 	return locations, nil
 }
 
+// EachByArea calls fn for every location in the area without loading the whole result set.
+func (r locationRepository) EachByArea(points map[string]map[string]float32, filter domain.LocationFilter, fn func(domain.Location) error) error {
+	cond := db.Cond{"lat >": points["UpperLeftPoint"]["lat"], "lat <": points["BottomRightPoint"]["lat"], "lon <": points["UpperLeftPoint"]["lon"], "lon >": points["BottomRightPoint"]["lon"], "deleted_date": nil}
+	return r.each(r.coll.Find(r.applyFilter(cond, filter)).OrderBy("id"), fn)
+}
+
+// EachByUserId calls fn for every location of the user without loading the whole result set.
+func (r locationRepository) EachByUserId(user_id uint64, filter domain.LocationFilter, fn func(domain.Location) error) error {
+	return r.each(r.coll.Find(r.applyFilter(db.Cond{"user_id": user_id, "deleted_date": nil}, filter)).OrderBy("id"), fn)
+}
+
+func (r locationRepository) each(res db.Result, fn func(domain.Location) error) error {
+	defer res.Close()
+
+	var loc location
+	for res.Next(&loc) {
+		err := fn(r.mapModelToDomain(loc))
+		if err != nil {
+			return err
+		}
+		loc = location{}
+	}
+	return res.Err()
+}
+
+// Search finds locations matching every word of the text as a prefix, ranked by relevance.
+func (r locationRepository) Search(p domain.Pagination, search domain.LocationSearch) (domain.Locations, error) {
+	var data []location
+	tsQuery := prefixTsQuery(search.Text)
+	cond := db.Cond{"deleted_date": nil}
+	if search.Points != nil {
+		cond["lat >"] = search.Points["UpperLeftPoint"]["lat"]
+		cond["lat <"] = search.Points["BottomRightPoint"]["lat"]
+		cond["lon <"] = search.Points["UpperLeftPoint"]["lon"]
+		cond["lon >"] = search.Points["BottomRightPoint"]["lon"]
+	}
+	query := r.coll.Find(db.And(
+		r.applyFilter(cond, search.Filter),
+		db.Raw("search_vector @@ to_tsquery('simple', ?)", tsQuery),
+	)).OrderBy(db.Raw("ts_rank(search_vector, to_tsquery('simple', ?)) DESC", tsQuery), "id")
+	res := query.Paginate(uint(p.CountPerPage))
+	err := res.Page(uint(p.Page)).All(&data)
+	if err != nil {
+		return domain.Locations{}, err
+	}
+
+	locations := r.mapModelToDomainPagination(data)
+
+	totalCount, err := res.TotalEntries()
+	if err != nil {
+		return domain.Locations{}, err
+	}
+
+	locations.Total = totalCount
+	locations.Pages = uint(math.Ceil(float64(locations.Total) / float64(p.CountPerPage)))
+
+	return locations, nil
+}
+
+// prefixTsQuery turns user input like "школа 5" into "школа:* & 5:*".
+func prefixTsQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i := range words {
+		words[i] += ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
 func (r locationRepository) FindById(id uint64) (domain.Location, error) {
 	var loc location
-	err := r.coll.Find(db.Cond{"id": id}).One(&loc)
+	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).One(&loc)
+	if err != nil {
+		return domain.Location{}, err
+	}
+
+	var groups []locationGroup
+	err = r.sess.Collection(LocationGroupsTableName).Find(db.Cond{"location_id": id}).All(&groups)
 	if err != nil {
 		return domain.Location{}, err
 	}
-	return r.mapModelToDomain(loc), nil
+
+	result := r.mapModelToDomain(loc)
+	for _, g := range groups {
+		result.GroupIds = append(result.GroupIds, g.GroupId)
+	}
+	return result, nil
+}
+
+func (r locationRepository) FindNearest(search domain.NearestSearch) (domain.Locations, error) {
+	var data []locationWithDistance
+	// Rough bounding box in degrees, so the distance is computed only for nearby rows.
+	latDelta := search.Radius / 111320
+	lonDelta := search.Radius / (111320 * math.Max(math.Cos(search.Lat*math.Pi/180), 0.01))
+	query := `SELECT * FROM (
+		SELECT *, ` + haversineExpr + ` AS distance
+		FROM ` + LocationsTableName + `
+		WHERE deleted_date IS NULL
+			AND lat BETWEEN ? AND ?
+			AND lon BETWEEN ? AND ?
+			AND ` + visibleToExpr + `
+	) AS nearest
+	WHERE distance <= ?
+	ORDER BY distance
+	LIMIT ?`
+	err := r.sess.SQL().Iterator(
+		query,
+		earthRadius, search.Lat, search.Lat, search.Lon,
+		search.Lat-latDelta, search.Lat+latDelta,
+		search.Lon-lonDelta, search.Lon+lonDelta,
+		search.ViewerId, search.ViewerId, search.ViewerId,
+		search.Radius,
+		search.Limit,
+	).All(&data)
+	if err != nil {
+		return domain.Locations{}, err
+	}
+
+	items := make([]domain.Location, len(data))
+	for i := range data {
+		items[i] = r.mapModelToDomain(data[i].location)
+		distance := data[i].Distance
+		items[i].Distance = &distance
+	}
+
+	return domain.Locations{Items: items, Total: uint64(len(items)), Pages: 1}, nil
+}
+
+func (r locationRepository) applyFilter(cond db.Cond, filter domain.LocationFilter) db.LogicalExpr {
+	if len(filter.Types) > 0 {
+		cond["type IN"] = filter.Types
+	}
+	conds := []db.LogicalExpr{cond}
+	if len(filter.Attributes) > 0 {
+		conds = append(conds, db.Raw("attributes @> ?", postgresql.StringArray(filter.Attributes)))
+	}
+	if filter.OpenNow {
+		weekday := int(filter.OpenAt.Weekday())
+		previous := (weekday + 6) % 7
+		now := filter.OpenAt.Format("15:04")
+		conds = append(conds, db.Raw(openNowExpr, weekday, now, now, previous, now))
+	}
+	if filter.ViewerId != 0 {
+		conds = append(conds, db.Raw(visibleToExpr, filter.ViewerId, filter.ViewerId, filter.ViewerId))
+	}
+	return db.And(conds...)
 }
 
 func (r locationRepository) mapDomainToModel(d domain.Location) location {
 	return location{
-		Id:          d.Id,
-		UserId:      d.UserId,
-		Type:        d.Type,
-		Address:     d.Address,
-		Title:       d.Title,
-		Description: d.Description,
-		Lat:         d.Lat,
-		Lon:         d.Lon,
-		CreatedDate: d.CreatedDate,
-		UpdatedDate: d.UpdatedDate,
-		DeletedDate: d.DeletedDate,
+		Id:           d.Id,
+		UserId:       d.UserId,
+		Type:         d.Type,
+		Address:      d.Address,
+		Title:        d.Title,
+		Description:  d.Description,
+		Lat:          d.Lat,
+		Lon:          d.Lon,
+		Visibility:   d.Visibility,
+		Capacity:     d.Capacity,
+		Occupancy:    d.Occupancy,
+		OpeningHours: r.mapOpeningHoursToModel(d.OpeningHours),
+		Attributes:   postgresql.StringArray(append([]string{}, d.Attributes...)),
+		CreatedDate:  d.CreatedDate,
+		UpdatedDate:  d.UpdatedDate,
+		DeletedDate:  d.DeletedDate,
 	}
 }
 
 func (r locationRepository) mapModelToDomain(m location) domain.Location {
 	return domain.Location{
-		Id:          m.Id,
-		UserId:      m.UserId,
-		Type:        m.Type,
-		Address:     m.Address,
-		Title:       m.Title,
-		Description: m.Description,
-		Lat:         m.Lat,
-		Lon:         m.Lon,
-		CreatedDate: m.CreatedDate,
-		UpdatedDate: m.UpdatedDate,
-		DeletedDate: m.DeletedDate,
+		Id:           m.Id,
+		UserId:       m.UserId,
+		Type:         m.Type,
+		Address:      m.Address,
+		Title:        m.Title,
+		Description:  m.Description,
+		Lat:          m.Lat,
+		Lon:          m.Lon,
+		Visibility:   m.Visibility,
+		Capacity:     m.Capacity,
+		Occupancy:    m.Occupancy,
+		OpeningHours: r.mapOpeningHoursToDomain(m.OpeningHours),
+		Attributes:   []string(m.Attributes),
+		CreatedDate:  m.CreatedDate,
+		UpdatedDate:  m.UpdatedDate,
+		DeletedDate:  m.DeletedDate,
+	}
+}
+
+func (r locationRepository) mapOpeningHoursToModel(d domain.OpeningHours) openingHours {
+	periods := make([]openingPeriod, len(d.Periods))
+	for i, p := range d.Periods {
+		periods[i] = openingPeriod{Weekday: int(p.Weekday), Opens: p.Opens, Closes: p.Closes}
+	}
+	return openingHours{AlwaysOpen: d.AlwaysOpen, Periods: periods}
+}
+
+func (r locationRepository) mapOpeningHoursToDomain(m openingHours) domain.OpeningHours {
+	periods := make([]domain.OpeningPeriod, len(m.Periods))
+	for i, p := range m.Periods {
+		periods[i] = domain.OpeningPeriod{Weekday: time.Weekday(p.Weekday), Opens: p.Opens, Closes: p.Closes}
 	}
+	return domain.OpeningHours{AlwaysOpen: m.AlwaysOpen, Periods: periods}
 }
 
 func (f locationRepository) mapModelToDomainPagination(locations []location) domain.Locations {
@@ -0,0 +1,215 @@
+package database
+
+import (
+	"boilerplate/internal/domain"
+	"database/sql/driver"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
+)
+
+const LocationRevisionsTableName = "location_revisions"
+
+type locationRevision struct {
+	Id          uint64           `db:"id,omitempty"`
+	LocationId  uint64           `db:"location_id"`
+	UserId      uint64           `db:"user_id"`
+	Action      string           `db:"action"`
+	Snapshot    locationSnapshot `db:"snapshot"`
+	Diff        revisionDiff     `db:"diff"`
+	CreatedDate time.Time        `db:"created_date,omitempty"`
+}
+
+type locationSnapshot struct {
+	UserId       uint64       `json:"user_id"`
+	Type         string       `json:"type"`
+	Address      string       `json:"address"`
+	Title        string       `json:"title"`
+	Description  string       `json:"description"`
+	Lat          float64      `json:"lat"`
+	Lon          float64      `json:"lon"`
+	Visibility   string       `json:"visibility"`
+	GroupIds     []uint64     `json:"group_ids"`
+	Capacity     uint64       `json:"capacity"`
+	OpeningHours openingHours `json:"opening_hours"`
+	Attributes   []string     `json:"attributes"`
+}
+
+func (s locationSnapshot) Value() (driver.Value, error) {
+	return postgresql.JSONBValue(s)
+}
+
+func (s *locationSnapshot) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(s, src)
+}
+
+type fieldChange struct {
+	Old json.RawMessage `json:"old"`
+	New json.RawMessage `json:"new"`
+}
+
+type revisionDiff map[string]fieldChange
+
+func (d revisionDiff) Value() (driver.Value, error) {
+	if d == nil {
+		d = revisionDiff{}
+	}
+	return postgresql.JSONBValue(map[string]fieldChange(d))
+}
+
+func (d *revisionDiff) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(d, src)
+}
+
+type LocationRevisionRepository interface {
+	FindById(id uint64) (domain.LocationRevision, error)
+	GetList(p domain.Pagination, locationId uint64) (domain.LocationRevisions, error)
+}
+
+type locationRevisionRepository struct {
+	coll db.Collection
+}
+
+func NewLocationRevisionRepository(dbSession db.Session) locationRevisionRepository {
+	return locationRevisionRepository{
+		coll: dbSession.Collection(LocationRevisionsTableName),
+	}
+}
+
+// saveLocationRevision inserts the revision in the transaction of the location change it records.
+func saveLocationRevision(tx db.Session, revision domain.LocationRevision) error {
+	rev, err := locationRevisionRepository{}.mapDomainToModel(revision)
+	if err != nil {
+		return err
+	}
+	rev.CreatedDate = time.Now()
+	_, err = tx.Collection(LocationRevisionsTableName).Insert(rev)
+	return err
+}
+
+func (r locationRevisionRepository) FindById(id uint64) (domain.LocationRevision, error) {
+	var rev locationRevision
+	err := r.coll.Find(db.Cond{"id": id}).One(&rev)
+	if err != nil {
+		return domain.LocationRevision{}, err
+	}
+	return r.mapModelToDomain(rev), nil
+}
+
+func (r locationRevisionRepository) GetList(p domain.Pagination, locationId uint64) (domain.LocationRevisions, error) {
+	var data []locationRevision
+	query := r.coll.Find(db.Cond{"location_id": locationId}).OrderBy("-id")
+	res := query.Paginate(uint(p.CountPerPage))
+	err := res.Page(uint(p.Page)).All(&data)
+	if err != nil {
+		return domain.LocationRevisions{}, err
+	}
+
+	revisions := r.mapModelToDomainPagination(data)
+
+	totalCount, err := res.TotalEntries()
+	if err != nil {
+		return domain.LocationRevisions{}, err
+	}
+
+	revisions.Total = totalCount
+	revisions.Pages = uint(math.Ceil(float64(revisions.Total) / float64(p.CountPerPage)))
+
+	return revisions, nil
+}
+
+func (r locationRevisionRepository) mapDomainToModel(d domain.LocationRevision) (locationRevision, error) {
+	diff := make(revisionDiff, len(d.Diff))
+	for name, change := range d.Diff {
+		oldValue, err := json.Marshal(r.mapValueToModel(change.Old))
+		if err != nil {
+			return locationRevision{}, err
+		}
+		newValue, err := json.Marshal(r.mapValueToModel(change.New))
+		if err != nil {
+			return locationRevision{}, err
+		}
+		diff[name] = fieldChange{Old: oldValue, New: newValue}
+	}
+
+	return locationRevision{
+		Id:          d.Id,
+		LocationId:  d.LocationId,
+		UserId:      d.UserId,
+		Action:      d.Action,
+		Snapshot:    r.mapSnapshotToModel(d.Snapshot),
+		Diff:        diff,
+		CreatedDate: d.CreatedDate,
+	}, nil
+}
+
+func (r locationRevisionRepository) mapModelToDomain(m locationRevision) domain.LocationRevision {
+	diff := make(map[string]domain.LocationFieldChange, len(m.Diff))
+	for name, change := range m.Diff {
+		diff[name] = domain.LocationFieldChange{Old: change.Old, New: change.New}
+	}
+
+	return domain.LocationRevision{
+		Id:          m.Id,
+		LocationId:  m.LocationId,
+		UserId:      m.UserId,
+		Action:      m.Action,
+		Snapshot:    r.mapSnapshotToDomain(m.Snapshot, m.LocationId),
+		Diff:        diff,
+		CreatedDate: m.CreatedDate,
+	}
+}
+
+// mapValueToModel keeps opening hours in the same JSON shape as the column.
+func (r locationRevisionRepository) mapValueToModel(value interface{}) interface{} {
+	if hours, ok := value.(domain.OpeningHours); ok {
+		return locationRepository{}.mapOpeningHoursToModel(hours)
+	}
+	return value
+}
+
+func (r locationRevisionRepository) mapSnapshotToModel(d domain.Location) locationSnapshot {
+	return locationSnapshot{
+		UserId:       d.UserId,
+		Type:         d.Type,
+		Address:      d.Address,
+		Title:        d.Title,
+		Description:  d.Description,
+		Lat:          d.Lat,
+		Lon:          d.Lon,
+		Visibility:   d.Visibility,
+		GroupIds:     d.GroupIds,
+		Capacity:     d.Capacity,
+		OpeningHours: locationRepository{}.mapOpeningHoursToModel(d.OpeningHours),
+		Attributes:   d.Attributes,
+	}
+}
+
+func (r locationRevisionRepository) mapSnapshotToDomain(m locationSnapshot, locationId uint64) domain.Location {
+	return domain.Location{
+		Id:           locationId,
+		UserId:       m.UserId,
+		Type:         m.Type,
+		Address:      m.Address,
+		Title:        m.Title,
+		Description:  m.Description,
+		Lat:          m.Lat,
+		Lon:          m.Lon,
+		Visibility:   m.Visibility,
+		GroupIds:     m.GroupIds,
+		Capacity:     m.Capacity,
+		OpeningHours: locationRepository{}.mapOpeningHoursToDomain(m.OpeningHours),
+		Attributes:   m.Attributes,
+	}
+}
+
+func (f locationRevisionRepository) mapModelToDomainPagination(revisions []locationRevision) domain.LocationRevisions {
+	new_revisions := make([]domain.LocationRevision, len(revisions))
+	for i, revision := range revisions {
+		new_revisions[i] = f.mapModelToDomain(revision)
+	}
+	return domain.LocationRevisions{Items: new_revisions}
+}
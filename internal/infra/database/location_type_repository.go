@@ -0,0 +1,133 @@
+package database
+
+import (
+	"boilerplate/internal/domain"
+	"math"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+const (
+	LocationTypesTableName = "location_types"
+	// LocationTypeNameKey keeps type names unique.
+	LocationTypeNameKey = "location_types_name_key"
+	// LocationTypeForeignKey stops a type used by locations from being deleted.
+	LocationTypeForeignKey = "fk_locations_type"
+)
+
+type locationType struct {
+	Id          uint64    `db:"id,omitempty"`
+	Name        string    `db:"name"`
+	Title       string    `db:"title"`
+	CreatedDate time.Time `db:"created_date,omitempty"`
+	UpdatedDate time.Time `db:"updated_date,omitempty"`
+}
+
+type LocationTypeRepository interface {
+	Save(locationType domain.LocationType) (domain.LocationType, error)
+	Update(locationType domain.LocationType) (domain.LocationType, error)
+	Delete(id uint64) error
+	FindById(id uint64) (domain.LocationType, error)
+	Exists(name string) (bool, error)
+	GetList(p domain.Pagination) (domain.LocationTypes, error)
+}
+
+type locationTypeRepository struct {
+	coll db.Collection
+}
+
+func NewLocationTypeRepository(dbSession db.Session) locationTypeRepository {
+	return locationTypeRepository{
+		coll: dbSession.Collection(LocationTypesTableName),
+	}
+}
+
+func (r locationTypeRepository) Save(locationType domain.LocationType) (domain.LocationType, error) {
+	lt := r.mapDomainToModel(locationType)
+	lt.CreatedDate, lt.UpdatedDate = time.Now(), time.Now()
+	err := r.coll.InsertReturning(&lt)
+	if err != nil {
+		return domain.LocationType{}, err
+	}
+	return r.mapModelToDomain(lt), nil
+}
+
+// Update changes the type, a new name is carried over to its locations by the foreign key.
+func (r locationTypeRepository) Update(locationType domain.LocationType) (domain.LocationType, error) {
+	lt := r.mapDomainToModel(locationType)
+	lt.UpdatedDate = time.Now()
+	err := r.coll.Find(db.Cond{"id": lt.Id}).Update(&lt)
+	if err != nil {
+		return domain.LocationType{}, err
+	}
+	return r.mapModelToDomain(lt), nil
+}
+
+// Delete removes a type no location uses, otherwise the foreign key is violated.
+func (r locationTypeRepository) Delete(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id}).Delete()
+}
+
+func (r locationTypeRepository) FindById(id uint64) (domain.LocationType, error) {
+	var lt locationType
+	err := r.coll.Find(db.Cond{"id": id}).One(&lt)
+	if err != nil {
+		return domain.LocationType{}, err
+	}
+	return r.mapModelToDomain(lt), nil
+}
+
+func (r locationTypeRepository) Exists(name string) (bool, error) {
+	return r.coll.Find(db.Cond{"name": name}).Exists()
+}
+
+func (r locationTypeRepository) GetList(p domain.Pagination) (domain.LocationTypes, error) {
+	var data []locationType
+	query := r.coll.Find().OrderBy("name")
+	res := query.Paginate(uint(p.CountPerPage))
+	err := res.Page(uint(p.Page)).All(&data)
+	if err != nil {
+		return domain.LocationTypes{}, err
+	}
+
+	locationTypes := r.mapModelToDomainPagination(data)
+
+	totalCount, err := res.TotalEntries()
+	if err != nil {
+		return domain.LocationTypes{}, err
+	}
+
+	locationTypes.Total = totalCount
+	locationTypes.Pages = uint(math.Ceil(float64(locationTypes.Total) / float64(p.CountPerPage)))
+
+	return locationTypes, nil
+}
+
+func (r locationTypeRepository) mapDomainToModel(d domain.LocationType) locationType {
+	return locationType{
+		Id:          d.Id,
+		Name:        d.Name,
+		Title:       d.Title,
+		CreatedDate: d.CreatedDate,
+		UpdatedDate: d.UpdatedDate,
+	}
+}
+
+func (r locationTypeRepository) mapModelToDomain(m locationType) domain.LocationType {
+	return domain.LocationType{
+		Id:          m.Id,
+		Name:        m.Name,
+		Title:       m.Title,
+		CreatedDate: m.CreatedDate,
+		UpdatedDate: m.UpdatedDate,
+	}
+}
+
+func (f locationTypeRepository) mapModelToDomainPagination(locationTypes []locationType) domain.LocationTypes {
+	new_location_types := make([]domain.LocationType, len(locationTypes))
+	for i, location_type := range locationTypes {
+		new_location_types[i] = f.mapModelToDomain(location_type)
+	}
+	return domain.LocationTypes{Items: new_location_types}
+}
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS location_types
+(
+    id           SERIAL PRIMARY KEY,
+    name         TEXT NOT NULL,
+    title        TEXT,
+    created_date TIMESTAMP,
+    updated_date TIMESTAMP,
+    deleted_date TIMESTAMP NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS location_types_name_idx ON location_types (name) WHERE deleted_date IS NULL;
+
+INSERT INTO location_types (name, title, created_date, updated_date)
+VALUES ('shelter', 'Shelter', NOW(), NOW());
+
+INSERT INTO location_types (name, title, created_date, updated_date)
+SELECT DISTINCT LOWER(TRIM(type)), LOWER(TRIM(type)), NOW(), NOW()
+FROM locations
+WHERE type IS NOT NULL AND TRIM(type) <> '' AND LOWER(TRIM(type)) <> 'shelter';
+
+UPDATE locations SET type = LOWER(TRIM(type));
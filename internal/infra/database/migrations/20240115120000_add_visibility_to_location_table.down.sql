@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS location_groups;
+
+ALTER TABLE locations
+DROP COLUMN visibility;
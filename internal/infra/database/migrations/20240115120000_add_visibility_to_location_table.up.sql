@@ -0,0 +1,17 @@
+-- Existing locations stay visible to everyone, new ones are private unless stated otherwise.
+ALTER TABLE locations
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public';
+
+ALTER TABLE locations
+ALTER COLUMN visibility SET DEFAULT 'private';
+
+CREATE TABLE IF NOT EXISTS location_groups
+(
+    location_id INTEGER NOT NULL,
+    group_id    INTEGER NOT NULL,
+    CONSTRAINT location_groups_pkey PRIMARY KEY (location_id, group_id),
+    CONSTRAINT fk_location_id FOREIGN KEY (location_id) REFERENCES locations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_group_id FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS location_groups_group_id_idx ON location_groups (group_id);
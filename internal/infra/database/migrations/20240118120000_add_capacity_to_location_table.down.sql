@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS location_occupancy_events;
+
+ALTER TABLE locations
+DROP COLUMN capacity,
+DROP COLUMN occupancy;
@@ -0,0 +1,17 @@
+ALTER TABLE locations
+ADD COLUMN capacity  INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN occupancy INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS location_occupancy_events
+(
+    id           SERIAL PRIMARY KEY,
+    location_id  INTEGER NOT NULL,
+    user_id      INTEGER NOT NULL,
+    action       TEXT    NOT NULL,
+    occupancy    INTEGER NOT NULL,
+    created_date TIMESTAMP,
+    CONSTRAINT fk_location_id FOREIGN KEY (location_id) REFERENCES locations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS location_occupancy_events_location_id_idx ON location_occupancy_events (location_id, created_date);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS locations_attributes_idx;
+
+ALTER TABLE locations
+DROP COLUMN opening_hours,
+DROP COLUMN attributes;
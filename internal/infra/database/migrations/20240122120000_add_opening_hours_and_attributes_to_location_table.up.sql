@@ -0,0 +1,5 @@
+ALTER TABLE locations
+ADD COLUMN opening_hours JSONB  NOT NULL DEFAULT '{"always_open": false, "periods": []}',
+ADD COLUMN attributes    TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS locations_attributes_idx ON locations USING GIN (attributes);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS locations_search_vector_idx;
+
+ALTER TABLE locations
+DROP COLUMN search_vector;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS location_revisions
+(
+    id           SERIAL PRIMARY KEY,
+    location_id  INTEGER NOT NULL,
+    user_id      INTEGER NOT NULL,
+    action       TEXT    NOT NULL,
+    snapshot     JSONB   NOT NULL,
+    diff         JSONB   NOT NULL,
+    created_date TIMESTAMP,
+    CONSTRAINT fk_location_id FOREIGN KEY (location_id) REFERENCES locations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS location_revisions_location_id_idx ON location_revisions (location_id, created_date);
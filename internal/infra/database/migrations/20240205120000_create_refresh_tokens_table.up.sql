@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens
+(
+    token_hash   TEXT PRIMARY KEY,
+    user_id      INTEGER   NOT NULL,
+    session_uuid TEXT      NOT NULL,
+    expires_date TIMESTAMP NOT NULL,
+    used_date    TIMESTAMP NULL,
+    created_date TIMESTAMP,
+    CONSTRAINT fk_session FOREIGN KEY (user_id, session_uuid) REFERENCES sessions(user_id, uuid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_session_idx ON refresh_tokens (user_id, session_uuid);
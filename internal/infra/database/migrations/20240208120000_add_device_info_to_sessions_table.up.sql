@@ -0,0 +1,5 @@
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS created_date   TIMESTAMP NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS last_seen_date TIMESTAMP NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS user_agent     TEXT      NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS ip             TEXT      NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verified_date TIMESTAMP NULL;
+
+UPDATE users SET verified_date = created_date WHERE verified_date IS NULL;
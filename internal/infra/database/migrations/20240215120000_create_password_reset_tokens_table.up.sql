@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS password_reset_tokens
+(
+    token_hash   TEXT PRIMARY KEY,
+    user_id      INTEGER   NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_date TIMESTAMP NOT NULL,
+    used_date    TIMESTAMP NULL,
+    created_date TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS password_reset_tokens_user_id_idx ON password_reset_tokens (user_id);
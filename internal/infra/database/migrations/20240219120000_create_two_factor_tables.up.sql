@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS user_two_factors
+(
+    user_id        INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret         TEXT      NOT NULL,
+    last_used_step BIGINT    NOT NULL DEFAULT 0,
+    enabled_date   TIMESTAMP NULL,
+    created_date   TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes
+(
+    id           SERIAL PRIMARY KEY,
+    user_id      INTEGER   NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash    TEXT      NOT NULL,
+    used_date    TIMESTAMP NULL,
+    created_date TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS user_recovery_codes_user_id_idx ON user_recovery_codes (user_id);
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS user_identities
+(
+    id           SERIAL PRIMARY KEY,
+    user_id      INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    provider     TEXT    NOT NULL,
+    subject      TEXT    NOT NULL,
+    email        TEXT    NOT NULL DEFAULT '',
+    created_date TIMESTAMP,
+    CONSTRAINT user_identities_provider_subject_key UNIQUE (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_user_id_idx ON user_identities (user_id);
+
+CREATE TABLE IF NOT EXISTS oidc_auth_requests
+(
+    state         TEXT PRIMARY KEY,
+    provider      TEXT      NOT NULL,
+    code_verifier TEXT      NOT NULL,
+    nonce         TEXT      NOT NULL,
+    expires_date  TIMESTAMP NOT NULL,
+    created_date  TIMESTAMP
+);
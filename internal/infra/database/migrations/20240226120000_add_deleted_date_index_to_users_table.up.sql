@@ -0,0 +1,2 @@
+-- Deleted accounts are looked up by the purge job and by restore.
+CREATE INDEX IF NOT EXISTS users_deleted_date_idx ON users (deleted_date) WHERE deleted_date IS NOT NULL;
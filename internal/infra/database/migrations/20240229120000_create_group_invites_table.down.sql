@@ -0,0 +1,6 @@
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS access_code TEXT;
+
+UPDATE groups g
+SET access_code = (SELECT i.code FROM group_invites i WHERE i.group_id = g.id ORDER BY i.id LIMIT 1);
+
+DROP TABLE IF EXISTS group_invites;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS group_invites
+(
+    id           SERIAL PRIMARY KEY,
+    group_id     INTEGER NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    user_id      INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code         TEXT    NOT NULL,
+    max_uses     INTEGER NOT NULL DEFAULT 0,
+    uses         INTEGER NOT NULL DEFAULT 0,
+    expires_date TIMESTAMP NULL,
+    revoked_date TIMESTAMP NULL,
+    created_date TIMESTAMP,
+    CONSTRAINT group_invites_code_key UNIQUE (code)
+);
+
+CREATE INDEX IF NOT EXISTS group_invites_group_id_idx ON group_invites (group_id);
+
+-- The old codes were guessable, they are kept already expired so the down migration can restore them.
+INSERT INTO group_invites (group_id, user_id, code, expires_date, created_date)
+SELECT DISTINCT ON (access_code) id, user_id, access_code, now(), now()
+FROM groups
+WHERE access_code IS NOT NULL AND access_code <> '' AND deleted_date IS NULL
+ORDER BY access_code, id;
+
+ALTER TABLE groups DROP COLUMN IF EXISTS access_code;
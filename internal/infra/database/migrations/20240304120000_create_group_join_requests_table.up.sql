@@ -0,0 +1,17 @@
+ALTER TABLE groups
+ADD COLUMN requires_approval BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS group_join_requests
+(
+    id           SERIAL PRIMARY KEY,
+    group_id     INTEGER NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    user_id      INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status       TEXT    NOT NULL,
+    decided_by   INTEGER NULL REFERENCES users (id) ON DELETE SET NULL,
+    decided_date TIMESTAMP NULL,
+    created_date TIMESTAMP,
+    updated_date TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS group_join_requests_group_id_idx ON group_join_requests (group_id, status);
+CREATE UNIQUE INDEX IF NOT EXISTS group_join_requests_pending_key ON group_join_requests (group_id, user_id) WHERE status = 'pending';
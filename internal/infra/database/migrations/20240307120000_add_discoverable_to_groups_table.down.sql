@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS group_members_user_id_idx;
+DROP INDEX IF EXISTS groups_discoverable_title_idx;
+
+ALTER TABLE groups DROP COLUMN IF EXISTS discoverable;
@@ -0,0 +1,5 @@
+ALTER TABLE groups
+ADD COLUMN discoverable BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS groups_discoverable_title_idx ON groups (title) WHERE discoverable AND deleted_date IS NULL;
+CREATE INDEX IF NOT EXISTS group_members_user_id_idx ON group_members (user_id) WHERE deleted_date IS NULL;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS group_transfers;
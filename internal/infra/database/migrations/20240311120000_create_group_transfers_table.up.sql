@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS group_transfers
+(
+    id           SERIAL PRIMARY KEY,
+    group_id     INTEGER NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    from_user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    to_user_id   INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status       TEXT    NOT NULL,
+    decided_date TIMESTAMP NULL,
+    created_date TIMESTAMP,
+    updated_date TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS group_transfers_to_user_id_idx ON group_transfers (to_user_id, status);
+CREATE UNIQUE INDEX IF NOT EXISTS group_transfers_pending_key ON group_transfers (group_id) WHERE status = 'pending';
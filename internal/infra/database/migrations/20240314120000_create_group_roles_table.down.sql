@@ -0,0 +1,11 @@
+ALTER TABLE group_members
+ADD COLUMN access_level TEXT;
+
+UPDATE group_members gm
+SET access_level = CASE WHEN gr.name IN ('moderator', 'admin') THEN gr.name ELSE 'casual' END
+FROM group_roles gr
+WHERE gr.id = gm.role_id;
+
+ALTER TABLE group_members DROP COLUMN IF EXISTS role_id;
+
+DROP TABLE IF EXISTS group_roles;
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS group_roles
+(
+    id           SERIAL PRIMARY KEY,
+    group_id     INTEGER NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    name         TEXT    NOT NULL,
+    permissions  TEXT[]  NOT NULL DEFAULT '{}',
+    is_default   BOOLEAN NOT NULL DEFAULT false,
+    created_date TIMESTAMP,
+    updated_date TIMESTAMP,
+    CONSTRAINT group_roles_name_key UNIQUE (group_id, name)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS group_roles_default_key ON group_roles (group_id) WHERE is_default;
+
+INSERT INTO group_roles (group_id, name, permissions, is_default, created_date, updated_date)
+SELECT g.id, r.name, r.permissions, r.is_default, now(), now()
+FROM groups g
+CROSS JOIN (VALUES
+    ('casual', '{}'::TEXT[], true),
+    ('moderator', '{members.view,members.locate,members.approve}'::TEXT[], false),
+    ('admin', '{members.view,members.locate,members.approve,members.manage,group.edit,alerts.send}'::TEXT[], false)
+) AS r (name, permissions, is_default);
+
+ALTER TABLE group_members
+ADD COLUMN role_id INTEGER NULL REFERENCES group_roles (id);
+
+UPDATE group_members gm
+SET role_id = gr.id
+FROM group_roles gr
+WHERE gr.group_id = gm.group_id
+  AND gr.name = CASE WHEN gm.access_level IN ('moderator', 'admin') THEN gm.access_level ELSE 'casual' END;
+
+ALTER TABLE group_members ALTER COLUMN role_id SET NOT NULL;
+ALTER TABLE group_members DROP COLUMN access_level;
+
+CREATE INDEX IF NOT EXISTS group_members_role_id_idx ON group_members (role_id);
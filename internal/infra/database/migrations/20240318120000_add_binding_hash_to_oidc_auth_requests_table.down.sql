@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS oidc_auth_requests_expires_date_idx;
+
+ALTER TABLE oidc_auth_requests DROP COLUMN IF EXISTS binding_hash;
@@ -0,0 +1,6 @@
+DELETE FROM oidc_auth_requests;
+
+ALTER TABLE oidc_auth_requests
+ADD COLUMN binding_hash TEXT NOT NULL;
+
+CREATE INDEX IF NOT EXISTS oidc_auth_requests_expires_date_idx ON oidc_auth_requests (expires_date);
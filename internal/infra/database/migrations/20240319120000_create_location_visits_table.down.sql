@@ -0,0 +1 @@
+DROP TABLE IF EXISTS location_visits;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS location_visits
+(
+    id               SERIAL PRIMARY KEY,
+    location_id      INTEGER   NOT NULL,
+    user_id          INTEGER   NOT NULL,
+    checked_in_date  TIMESTAMP NOT NULL,
+    checked_out_date TIMESTAMP,
+    CONSTRAINT fk_location_id FOREIGN KEY (location_id) REFERENCES locations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- a user can be checked in at one location at a time
+CREATE UNIQUE INDEX IF NOT EXISTS location_visits_open_user_id_idx ON location_visits (user_id) WHERE checked_out_date IS NULL;
+
+-- check-ins made before visits were tracked can't be checked out, so the count starts over
+UPDATE locations SET occupancy = 0;
@@ -0,0 +1,8 @@
+ALTER TABLE locations
+DROP CONSTRAINT IF EXISTS fk_locations_type;
+
+ALTER TABLE location_types
+DROP CONSTRAINT IF EXISTS location_types_name_key,
+ADD COLUMN deleted_date TIMESTAMP NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS location_types_name_idx ON location_types (name) WHERE deleted_date IS NULL;
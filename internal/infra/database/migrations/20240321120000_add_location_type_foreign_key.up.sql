@@ -0,0 +1,30 @@
+-- a type in use can't be deleted and a rename follows into the locations,
+-- so deleted types are removed for good instead of being marked
+
+-- keep one row per name: the active one, otherwise the latest deleted
+DELETE FROM location_types a
+USING location_types b
+WHERE a.name = b.name
+  AND a.deleted_date IS NOT NULL
+  AND (b.deleted_date IS NULL OR (b.deleted_date IS NOT NULL AND a.id < b.id));
+
+-- bring back deleted types that locations still use
+UPDATE location_types
+SET deleted_date = NULL, updated_date = NOW()
+WHERE deleted_date IS NOT NULL AND name IN (SELECT type FROM locations);
+
+DELETE FROM location_types WHERE deleted_date IS NOT NULL;
+
+INSERT INTO location_types (name, title, created_date, updated_date)
+SELECT DISTINCT type, type, NOW(), NOW()
+FROM locations
+WHERE type IS NOT NULL AND type NOT IN (SELECT name FROM location_types);
+
+DROP INDEX IF EXISTS location_types_name_idx;
+
+ALTER TABLE location_types
+DROP COLUMN deleted_date,
+ADD CONSTRAINT location_types_name_key UNIQUE (name);
+
+ALTER TABLE locations
+ADD CONSTRAINT fk_locations_type FOREIGN KEY (type) REFERENCES location_types(name) ON UPDATE CASCADE ON DELETE RESTRICT;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS refresh_tokens_expires_date_idx ON refresh_tokens (expires_date);
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"boilerplate/internal/domain"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+const (
+	OccupancyEventsTableName = "location_occupancy_events"
+	LocationVisitsTableName  = "location_visits"
+)
+
+type occupancyEvent struct {
+	Id          uint64    `db:"id,omitempty"`
+	LocationId  uint64    `db:"location_id"`
+	UserId      uint64    `db:"user_id"`
+	Action      string    `db:"action"`
+	Occupancy   uint64    `db:"occupancy"`
+	CreatedDate time.Time `db:"created_date,omitempty"`
+}
+
+type locationVisit struct {
+	Id             uint64     `db:"id,omitempty"`
+	LocationId     uint64     `db:"location_id"`
+	UserId         uint64     `db:"user_id"`
+	CheckedInDate  time.Time  `db:"checked_in_date"`
+	CheckedOutDate *time.Time `db:"checked_out_date"`
+}
+
+type OccupancyEventRepository interface {
+	CheckIn(locationId uint64, userId uint64) (domain.OccupancyEvent, error)
+	CheckOut(locationId uint64, userId uint64) (domain.OccupancyEvent, error)
+	IsCheckedIn(userId uint64) (bool, error)
+	GetHistory(p domain.Pagination, locationId uint64) (domain.OccupancyEvents, error)
+	FindByUserId(userId uint64) (domain.OccupancyEvents, error)
+}
+
+type occupancyEventRepository struct {
+	coll db.Collection
+	sess db.Session
+}
+
+func NewOccupancyEventRepository(dbSession db.Session) occupancyEventRepository {
+	return occupancyEventRepository{
+		coll: dbSession.Collection(OccupancyEventsTableName),
+		sess: dbSession,
+	}
+}
+
+// CheckIn opens a visit of the user and increments the occupancy unless the location is full.
+// Returns db.ErrNoMoreRows when nothing was updated. A user already checked in somewhere
+// violates the unique index of open visits.
+func (r occupancyEventRepository) CheckIn(locationId uint64, userId uint64) (domain.OccupancyEvent, error) {
+	query := `UPDATE ` + LocationsTableName + ` SET occupancy = occupancy + 1
+		WHERE id = ? AND deleted_date IS NULL AND (capacity = 0 OR occupancy < capacity)
+		RETURNING occupancy`
+	return r.change(query, locationId, userId, domain.CheckInAction, func(tx db.Session) error {
+		_, err := tx.Collection(LocationVisitsTableName).Insert(locationVisit{
+			LocationId:    locationId,
+			UserId:        userId,
+			CheckedInDate: time.Now(),
+		})
+		return err
+	})
+}
+
+// CheckOut closes the open visit of the user at the location and decrements the occupancy.
+// Returns db.ErrNoMoreRows when the user is not checked in there.
+func (r occupancyEventRepository) CheckOut(locationId uint64, userId uint64) (domain.OccupancyEvent, error) {
+	query := `UPDATE ` + LocationsTableName + ` SET occupancy = occupancy - 1
+		WHERE id = ? AND deleted_date IS NULL AND occupancy > 0
+		RETURNING occupancy`
+	return r.change(query, locationId, userId, domain.CheckOutAction, func(tx db.Session) error {
+		return updateOne(tx.SQL().
+			Update(LocationVisitsTableName).
+			Set("checked_out_date", time.Now()).
+			Where(db.Cond{"location_id": locationId, "user_id": userId, "checked_out_date": nil}))
+	})
+}
+
+// IsCheckedIn reports whether the user has an open visit at any location.
+func (r occupancyEventRepository) IsCheckedIn(userId uint64) (bool, error) {
+	return r.sess.Collection(LocationVisitsTableName).Find(db.Cond{"user_id": userId, "checked_out_date": nil}).Exists()
+}
+
+func (r occupancyEventRepository) GetHistory(p domain.Pagination, locationId uint64) (domain.OccupancyEvents, error) {
+	var data []occupancyEvent
+	query := r.coll.Find(db.Cond{"location_id": locationId}).OrderBy("-created_date")
+	res := query.Paginate(uint(p.CountPerPage))
+	err := res.Page(uint(p.Page)).All(&data)
+	if err != nil {
+		return domain.OccupancyEvents{}, err
+	}
+
+	events := r.mapModelToDomainPagination(data)
+
+	totalCount, err := res.TotalEntries()
+	if err != nil {
+		return domain.OccupancyEvents{}, err
+	}
+
+	events.Total = totalCount
+	events.Pages = uint(math.Ceil(float64(events.Total) / float64(p.CountPerPage)))
+
+	return events, nil
+}
+
+func (r occupancyEventRepository) FindByUserId(userId uint64) (domain.OccupancyEvents, error) {
+	var data []occupancyEvent
+	err := r.coll.Find(db.Cond{"user_id": userId}).OrderBy("created_date").All(&data)
+	if err != nil {
+		return domain.OccupancyEvents{}, err
+	}
+
+	events := r.mapModelToDomainPagination(data)
+	events.Total = uint64(len(data))
+	return events, nil
+}
+
+// change records the visit change of the user and applies the occupancy query in one transaction.
+func (r occupancyEventRepository) change(query string, locationId uint64, userId uint64, action string, visit func(tx db.Session) error) (domain.OccupancyEvent, error) {
+	var event occupancyEvent
+	err := r.sess.Tx(func(tx db.Session) error {
+		err := visit(tx)
+		if err != nil {
+			return err
+		}
+
+		row, err := tx.SQL().QueryRow(query, locationId)
+		if err != nil {
+			return err
+		}
+		var occupancy uint64
+		err = row.Scan(&occupancy)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return db.ErrNoMoreRows
+			}
+			return err
+		}
+
+		event = occupancyEvent{
+			LocationId:  locationId,
+			UserId:      userId,
+			Action:      action,
+			Occupancy:   occupancy,
+			CreatedDate: time.Now(),
+		}
+		return tx.Collection(OccupancyEventsTableName).InsertReturning(&event)
+	})
+	if err != nil {
+		return domain.OccupancyEvent{}, err
+	}
+	return r.mapModelToDomain(event), nil
+}
+
+func (r occupancyEventRepository) mapModelToDomain(m occupancyEvent) domain.OccupancyEvent {
+	return domain.OccupancyEvent{
+		Id:          m.Id,
+		LocationId:  m.LocationId,
+		UserId:      m.UserId,
+		Action:      m.Action,
+		Occupancy:   m.Occupancy,
+		CreatedDate: m.CreatedDate,
+	}
+}
+
+func (f occupancyEventRepository) mapModelToDomainPagination(events []occupancyEvent) domain.OccupancyEvents {
+	new_events := make([]domain.OccupancyEvent, len(events))
+	for i, event := range events {
+		new_events[i] = f.mapModelToDomain(event)
+	}
+	return domain.OccupancyEvents{Items: new_events}
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"boilerplate/internal/domain"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+const PasswordResetTokensTableName = "password_reset_tokens"
+
+type passwordResetToken struct {
+	TokenHash   string     `db:"token_hash"`
+	UserId      uint64     `db:"user_id"`
+	ExpiresDate time.Time  `db:"expires_date"`
+	UsedDate    *time.Time `db:"used_date,omitempty"`
+	CreatedDate time.Time  `db:"created_date,omitempty"`
+}
+
+type PasswordResetTokenRepository interface {
+	Save(token domain.PasswordResetToken) error
+	FindByHash(hash string) (domain.PasswordResetToken, error)
+	Redeem(hash string, userId uint64, passwordHash string) error
+}
+
+type passwordResetTokenRepository struct {
+	coll db.Collection
+	sess db.Session
+}
+
+func NewPasswordResetTokenRepository(dbSession db.Session) PasswordResetTokenRepository {
+	return passwordResetTokenRepository{
+		coll: dbSession.Collection(PasswordResetTokensTableName),
+		sess: dbSession,
+	}
+}
+
+func (r passwordResetTokenRepository) Save(token domain.PasswordResetToken) error {
+	t := r.mapDomainToModel(token)
+	t.CreatedDate = time.Now()
+	_, err := r.coll.Insert(&t)
+	return err
+}
+
+func (r passwordResetTokenRepository) FindByHash(hash string) (domain.PasswordResetToken, error) {
+	var t passwordResetToken
+	err := r.coll.Find(db.Cond{"token_hash": hash}).One(&t)
+	if err != nil {
+		return domain.PasswordResetToken{}, err
+	}
+	return r.mapModelToDomain(t), nil
+}
+
+// Redeem uses the token to set the password of its user. The token and every other
+// outstanding token of the user are marked used, the password is changed and the user
+// is signed out everywhere in one transaction. Returns db.ErrNoMoreRows when the token
+// is already used or expired.
+func (r passwordResetTokenRepository) Redeem(hash string, userId uint64, passwordHash string) error {
+	return r.sess.Tx(func(tx db.Session) error {
+		err := updateOne(tx.SQL().
+			Update(PasswordResetTokensTableName).
+			Set("used_date", time.Now()).
+			Where(db.Cond{"token_hash": hash, "user_id": userId, "used_date": nil, "expires_date >": time.Now()}))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.SQL().
+			Update(PasswordResetTokensTableName).
+			Set("used_date", time.Now()).
+			Where(db.Cond{"user_id": userId, "used_date": nil}).
+			Exec()
+		if err != nil {
+			return err
+		}
+
+		err = updateOne(tx.SQL().
+			Update(UsersTableName).
+			Set("password", passwordHash, "updated_date", time.Now()).
+			Where(db.Cond{"id": userId, "deleted_date": nil}))
+		if err != nil {
+			return err
+		}
+
+		return tx.Collection(SessionsTableName).Find(db.Cond{"user_id": userId}).Delete()
+	})
+}
+
+func (r passwordResetTokenRepository) mapDomainToModel(d domain.PasswordResetToken) passwordResetToken {
+	return passwordResetToken{
+		TokenHash:   d.TokenHash,
+		UserId:      d.UserId,
+		ExpiresDate: d.ExpiresDate,
+		UsedDate:    d.UsedDate,
+		CreatedDate: d.CreatedDate,
+	}
+}
+
+func (r passwordResetTokenRepository) mapModelToDomain(m passwordResetToken) domain.PasswordResetToken {
+	return domain.PasswordResetToken{
+		TokenHash:   m.TokenHash,
+		UserId:      m.UserId,
+		ExpiresDate: m.ExpiresDate,
+		UsedDate:    m.UsedDate,
+		CreatedDate: m.CreatedDate,
+	}
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"boilerplate/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/upper/db/v4"
+)
+
+const RefreshTokensTableName = "refresh_tokens"
+
+type refreshToken struct {
+	TokenHash   string     `db:"token_hash"`
+	UserId      uint64     `db:"user_id"`
+	SessionUUID uuid.UUID  `db:"session_uuid"`
+	ExpiresDate time.Time  `db:"expires_date"`
+	UsedDate    *time.Time `db:"used_date,omitempty"`
+	CreatedDate time.Time  `db:"created_date,omitempty"`
+}
+
+type RefreshTokenRepository interface {
+	Save(token domain.RefreshToken) error
+	FindByHash(hash string) (domain.RefreshToken, error)
+	MarkUsed(hash string) (bool, error)
+	DeleteExpired() (int64, error)
+}
+
+type refreshTokenRepository struct {
+	coll db.Collection
+}
+
+func NewRefreshTokenRepository(dbSession db.Session) RefreshTokenRepository {
+	return refreshTokenRepository{
+		coll: dbSession.Collection(RefreshTokensTableName),
+	}
+}
+
+func (r refreshTokenRepository) Save(token domain.RefreshToken) error {
+	t := r.mapDomainToModel(token)
+	t.CreatedDate = time.Now()
+	_, err := r.coll.Insert(&t)
+	return err
+}
+
+func (r refreshTokenRepository) FindByHash(hash string) (domain.RefreshToken, error) {
+	var t refreshToken
+	err := r.coll.Find(db.Cond{"token_hash": hash}).One(&t)
+	if err != nil {
+		return domain.RefreshToken{}, err
+	}
+	return r.mapModelToDomain(t), nil
+}
+
+// MarkUsed flags the token as used, returns false if it was already used.
+func (r refreshTokenRepository) MarkUsed(hash string) (bool, error) {
+	res, err := r.coll.Session().SQL().
+		Update(RefreshTokensTableName).
+		Set("used_date", time.Now()).
+		Where(db.Cond{"token_hash": hash, "used_date": nil}).
+		Exec()
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// DeleteExpired removes the tokens past their expiry, they are refused anyway. Used tokens
+// that have not expired yet stay, presenting one again is how reuse is detected.
+func (r refreshTokenRepository) DeleteExpired() (int64, error) {
+	res, err := r.coll.Session().SQL().
+		DeleteFrom(RefreshTokensTableName).
+		Where(db.Cond{"expires_date <": time.Now()}).
+		Exec()
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r refreshTokenRepository) mapDomainToModel(d domain.RefreshToken) refreshToken {
+	return refreshToken{
+		TokenHash:   d.TokenHash,
+		UserId:      d.UserId,
+		SessionUUID: d.SessionUUID,
+		ExpiresDate: d.ExpiresDate,
+		UsedDate:    d.UsedDate,
+		CreatedDate: d.CreatedDate,
+	}
+}
+
+func (r refreshTokenRepository) mapModelToDomain(m refreshToken) domain.RefreshToken {
+	return domain.RefreshToken{
+		TokenHash:   m.TokenHash,
+		UserId:      m.UserId,
+		SessionUUID: m.SessionUUID,
+		ExpiresDate: m.ExpiresDate,
+		UsedDate:    m.UsedDate,
+		CreatedDate: m.CreatedDate,
+	}
+}
@@ -2,7 +2,8 @@ package database
 
 import (
 	"boilerplate/internal/domain"
-	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/upper/db/v4"
 )
@@ -10,14 +11,22 @@ import (
 const SessionsTableName = "sessions"
 
 type sessions struct {
-	UserId uint64    `db:"user_id"`
-	UUID   uuid.UUID `db:"uuid"`
+	UserId       uint64    `db:"user_id"`
+	UUID         uuid.UUID `db:"uuid"`
+	UserAgent    string    `db:"user_agent"`
+	Ip           string    `db:"ip"`
+	CreatedDate  time.Time `db:"created_date"`
+	LastSeenDate time.Time `db:"last_seen_date"`
 }
 
 type SessionRepository interface {
 	Save(sess domain.Session) error
-	Exists(sess domain.Session) error
+	Find(sess domain.Session) (domain.Session, error)
+	FindByUserId(userId uint64) ([]domain.Session, error)
+	Touch(sess domain.Session) error
 	Delete(sess domain.Session) error
+	DeleteAllExcept(sess domain.Session) error
+	DeleteByUserId(userId uint64) error
 }
 
 type sessionRepository struct {
@@ -32,6 +41,8 @@ func NewSessRepository(dbSession db.Session) SessionRepository {
 
 func (r sessionRepository) Save(sess domain.Session) error {
 	a := r.mapDomainToModel(sess)
+	a.CreatedDate = time.Now()
+	a.LastSeenDate = a.CreatedDate
 	err := r.coll.InsertReturning(&a)
 	if err != nil {
 		return err
@@ -40,21 +51,68 @@ func (r sessionRepository) Save(sess domain.Session) error {
 	return nil
 }
 
-func (r sessionRepository) Exists(sess domain.Session) error {
-	exists, err := r.coll.Find(db.Cond{"user_id": sess.UserId, "uuid": sess.UUID}).Exists()
-	if !exists {
-		err = fmt.Errorf("sess not found")
+func (r sessionRepository) Find(sess domain.Session) (domain.Session, error) {
+	var s sessions
+	err := r.coll.Find(db.Cond{"user_id": sess.UserId, "uuid": sess.UUID}).One(&s)
+	if err != nil {
+		return domain.Session{}, err
 	}
-	return err
+	return r.mapModelToDomain(s), nil
+}
+
+func (r sessionRepository) FindByUserId(userId uint64) ([]domain.Session, error) {
+	var ss []sessions
+	err := r.coll.Find(db.Cond{"user_id": userId}).OrderBy("-last_seen_date").All(&ss)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.Session, len(ss))
+	for i, s := range ss {
+		res[i] = r.mapModelToDomain(s)
+	}
+	return res, nil
+}
+
+// Touch updates the last seen date and the client address of the session.
+func (r sessionRepository) Touch(sess domain.Session) error {
+	return r.coll.Find(db.Cond{"user_id": sess.UserId, "uuid": sess.UUID}).
+		Update(map[string]interface{}{
+			"last_seen_date": time.Now(),
+			"ip":             sess.Ip,
+		})
 }
 
 func (r sessionRepository) Delete(sess domain.Session) error {
 	return r.coll.Find(db.Cond{"user_id": sess.UserId, "uuid": sess.UUID}).Delete()
 }
 
+func (r sessionRepository) DeleteAllExcept(sess domain.Session) error {
+	return r.coll.Find(db.Cond{"user_id": sess.UserId, "uuid !=": sess.UUID}).Delete()
+}
+
+func (r sessionRepository) DeleteByUserId(userId uint64) error {
+	return r.coll.Find(db.Cond{"user_id": userId}).Delete()
+}
+
 func (r sessionRepository) mapDomainToModel(d domain.Session) sessions {
 	return sessions{
-		UserId: d.UserId,
-		UUID:   d.UUID,
+		UserId:       d.UserId,
+		UUID:         d.UUID,
+		UserAgent:    d.UserAgent,
+		Ip:           d.Ip,
+		CreatedDate:  d.CreatedDate,
+		LastSeenDate: d.LastSeenDate,
+	}
+}
+
+func (r sessionRepository) mapModelToDomain(m sessions) domain.Session {
+	return domain.Session{
+		UserId:       m.UserId,
+		UUID:         m.UUID,
+		UserAgent:    m.UserAgent,
+		Ip:           m.Ip,
+		CreatedDate:  m.CreatedDate,
+		LastSeenDate: m.LastSeenDate,
 	}
 }
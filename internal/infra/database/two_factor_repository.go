@@ -0,0 +1,150 @@
+package database
+
+import (
+	"boilerplate/internal/domain"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+const (
+	TwoFactorsTableName    = "user_two_factors"
+	RecoveryCodesTableName = "user_recovery_codes"
+)
+
+type twoFactor struct {
+	UserId       uint64     `db:"user_id"`
+	Secret       string     `db:"secret"`
+	LastUsedStep int64      `db:"last_used_step"`
+	EnabledDate  *time.Time `db:"enabled_date"`
+	CreatedDate  time.Time  `db:"created_date"`
+}
+
+type recoveryCode struct {
+	Id          uint64     `db:"id,omitempty"`
+	UserId      uint64     `db:"user_id"`
+	CodeHash    string     `db:"code_hash"`
+	UsedDate    *time.Time `db:"used_date,omitempty"`
+	CreatedDate time.Time  `db:"created_date"`
+}
+
+type TwoFactorRepository interface {
+	Save(tf domain.TwoFactor) error
+	Find(userId uint64) (domain.TwoFactor, error)
+	Enable(userId uint64, step int64, codeHashes []string) error
+	UseStep(userId uint64, step int64) (bool, error)
+	UseRecoveryCode(userId uint64, codeHash string) (bool, error)
+	Delete(userId uint64) error
+}
+
+type twoFactorRepository struct {
+	coll     db.Collection
+	codeColl db.Collection
+	sess     db.Session
+}
+
+func NewTwoFactorRepository(dbSession db.Session) TwoFactorRepository {
+	return twoFactorRepository{
+		coll:     dbSession.Collection(TwoFactorsTableName),
+		codeColl: dbSession.Collection(RecoveryCodesTableName),
+		sess:     dbSession,
+	}
+}
+
+// Save stores a new pending enrolment, replacing a previous unconfirmed one.
+func (r twoFactorRepository) Save(tf domain.TwoFactor) error {
+	_, err := r.sess.SQL().Exec(
+		`INSERT INTO user_two_factors (user_id, secret, last_used_step, enabled_date, created_date)
+		VALUES (?, ?, 0, NULL, ?)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, enabled_date = NULL, created_date = EXCLUDED.created_date`,
+		tf.UserId, tf.Secret, time.Now(),
+	)
+	return err
+}
+
+func (r twoFactorRepository) Find(userId uint64) (domain.TwoFactor, error) {
+	var tf twoFactor
+	err := r.coll.Find(db.Cond{"user_id": userId}).One(&tf)
+	if err != nil {
+		return domain.TwoFactor{}, err
+	}
+	return r.mapModelToDomain(tf), nil
+}
+
+// Enable confirms the enrolment and replaces the recovery codes in one transaction.
+func (r twoFactorRepository) Enable(userId uint64, step int64, codeHashes []string) error {
+	return r.sess.Tx(func(tx db.Session) error {
+		err := tx.Collection(TwoFactorsTableName).
+			Find(db.Cond{"user_id": userId}).
+			Update(map[string]interface{}{"enabled_date": time.Now(), "last_used_step": step})
+		if err != nil {
+			return err
+		}
+
+		codes := tx.Collection(RecoveryCodesTableName)
+		err = codes.Find(db.Cond{"user_id": userId}).Delete()
+		if err != nil {
+			return err
+		}
+		for _, hash := range codeHashes {
+			_, err = codes.Insert(recoveryCode{UserId: userId, CodeHash: hash, CreatedDate: time.Now()})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UseStep records the TOTP step as used, returns false if the same or a later step was used before.
+func (r twoFactorRepository) UseStep(userId uint64, step int64) (bool, error) {
+	res, err := r.sess.SQL().
+		Update(TwoFactorsTableName).
+		Set("last_used_step", step).
+		Where(db.Cond{"user_id": userId, "last_used_step <": step}).
+		Exec()
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r twoFactorRepository) UseRecoveryCode(userId uint64, codeHash string) (bool, error) {
+	res, err := r.sess.SQL().
+		Update(RecoveryCodesTableName).
+		Set("used_date", time.Now()).
+		Where(db.Cond{"user_id": userId, "code_hash": codeHash, "used_date": nil}).
+		Exec()
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r twoFactorRepository) Delete(userId uint64) error {
+	return r.sess.Tx(func(tx db.Session) error {
+		err := tx.Collection(RecoveryCodesTableName).Find(db.Cond{"user_id": userId}).Delete()
+		if err != nil {
+			return err
+		}
+		return tx.Collection(TwoFactorsTableName).Find(db.Cond{"user_id": userId}).Delete()
+	})
+}
+
+func (r twoFactorRepository) mapModelToDomain(m twoFactor) domain.TwoFactor {
+	return domain.TwoFactor{
+		UserId:       m.UserId,
+		Secret:       m.Secret,
+		LastUsedStep: m.LastUsedStep,
+		EnabledDate:  m.EnabledDate,
+		CreatedDate:  m.CreatedDate,
+	}
+}
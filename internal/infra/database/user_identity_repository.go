@@ -0,0 +1,162 @@
+package database
+
+import (
+	"boilerplate/internal/domain"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+const (
+	UserIdentitiesTableName   = "user_identities"
+	OidcAuthRequestsTableName = "oidc_auth_requests"
+)
+
+type userIdentity struct {
+	Id          uint64    `db:"id,omitempty"`
+	UserId      uint64    `db:"user_id"`
+	Provider    string    `db:"provider"`
+	Subject     string    `db:"subject"`
+	Email       string    `db:"email"`
+	CreatedDate time.Time `db:"created_date,omitempty"`
+}
+
+type oidcAuthRequest struct {
+	State        string    `db:"state"`
+	Provider     string    `db:"provider"`
+	CodeVerifier string    `db:"code_verifier"`
+	Nonce        string    `db:"nonce"`
+	BindingHash  string    `db:"binding_hash"`
+	ExpiresDate  time.Time `db:"expires_date"`
+	CreatedDate  time.Time `db:"created_date,omitempty"`
+}
+
+type UserIdentityRepository interface {
+	Save(identity domain.UserIdentity) (domain.UserIdentity, error)
+	FindByProviderSubject(provider, subject string) (domain.UserIdentity, error)
+	FindByUserId(userId uint64) ([]domain.UserIdentity, error)
+	SaveAuthRequest(req domain.OidcAuthRequest) error
+	ConsumeAuthRequest(state, bindingHash string) (domain.OidcAuthRequest, error)
+}
+
+type userIdentityRepository struct {
+	coll        db.Collection
+	requestColl db.Collection
+	sess        db.Session
+}
+
+func NewUserIdentityRepository(dbSession db.Session) UserIdentityRepository {
+	return userIdentityRepository{
+		coll:        dbSession.Collection(UserIdentitiesTableName),
+		requestColl: dbSession.Collection(OidcAuthRequestsTableName),
+		sess:        dbSession,
+	}
+}
+
+func (r userIdentityRepository) Save(identity domain.UserIdentity) (domain.UserIdentity, error) {
+	i := r.mapDomainToModel(identity)
+	i.CreatedDate = time.Now()
+	err := r.coll.InsertReturning(&i)
+	if err != nil {
+		return domain.UserIdentity{}, err
+	}
+	return r.mapModelToDomain(i), nil
+}
+
+func (r userIdentityRepository) FindByProviderSubject(provider, subject string) (domain.UserIdentity, error) {
+	var i userIdentity
+	err := r.coll.Find(db.Cond{"provider": provider, "subject": subject}).One(&i)
+	if err != nil {
+		return domain.UserIdentity{}, err
+	}
+	return r.mapModelToDomain(i), nil
+}
+
+func (r userIdentityRepository) FindByUserId(userId uint64) ([]domain.UserIdentity, error) {
+	var data []userIdentity
+	err := r.coll.Find(db.Cond{"user_id": userId}).OrderBy("id").All(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	identities := make([]domain.UserIdentity, len(data))
+	for i, identity := range data {
+		identities[i] = r.mapModelToDomain(identity)
+	}
+	return identities, nil
+}
+
+// SaveAuthRequest stores a started login, dropping the abandoned ones on the way.
+func (r userIdentityRepository) SaveAuthRequest(req domain.OidcAuthRequest) error {
+	err := r.requestColl.Find(db.Cond{"expires_date <": time.Now()}).Delete()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.requestColl.Insert(oidcAuthRequest{
+		State:        req.State,
+		Provider:     req.Provider,
+		CodeVerifier: req.CodeVerifier,
+		Nonce:        req.Nonce,
+		BindingHash:  req.BindingHash,
+		ExpiresDate:  req.ExpiresDate,
+		CreatedDate:  time.Now(),
+	})
+	return err
+}
+
+// ConsumeAuthRequest deletes and returns the unexpired request started by the client with
+// the binding, so a state can be used only once and only by that client. Expired requests
+// are dropped on the way. Returns sql.ErrNoRows when there is no such request.
+func (r userIdentityRepository) ConsumeAuthRequest(state, bindingHash string) (domain.OidcAuthRequest, error) {
+	var req oidcAuthRequest
+	err := r.sess.Tx(func(tx db.Session) error {
+		err := tx.Collection(OidcAuthRequestsTableName).Find(db.Cond{"expires_date <": time.Now()}).Delete()
+		if err != nil {
+			return err
+		}
+
+		row, err := tx.SQL().QueryRow(
+			`DELETE FROM oidc_auth_requests WHERE state = ? AND binding_hash = ?
+			RETURNING state, provider, code_verifier, nonce, expires_date`,
+			state, bindingHash,
+		)
+		if err != nil {
+			return err
+		}
+		return row.Scan(&req.State, &req.Provider, &req.CodeVerifier, &req.Nonce, &req.ExpiresDate)
+	})
+	if err != nil {
+		return domain.OidcAuthRequest{}, err
+	}
+
+	return domain.OidcAuthRequest{
+		State:        req.State,
+		Provider:     req.Provider,
+		CodeVerifier: req.CodeVerifier,
+		Nonce:        req.Nonce,
+		ExpiresDate:  req.ExpiresDate,
+	}, nil
+}
+
+func (r userIdentityRepository) mapDomainToModel(d domain.UserIdentity) userIdentity {
+	return userIdentity{
+		Id:          d.Id,
+		UserId:      d.UserId,
+		Provider:    d.Provider,
+		Subject:     d.Subject,
+		Email:       d.Email,
+		CreatedDate: d.CreatedDate,
+	}
+}
+
+func (r userIdentityRepository) mapModelToDomain(m userIdentity) domain.UserIdentity {
+	return domain.UserIdentity{
+		Id:          m.Id,
+		UserId:      m.UserId,
+		Provider:    m.Provider,
+		Subject:     m.Subject,
+		Email:       m.Email,
+		CreatedDate: m.CreatedDate,
+	}
+}
@@ -10,23 +10,27 @@ import (
 const UsersTableName = "users"
 
 type user struct {
-	Id          uint64     `db:"id,omitempty"`
-	Name        string     `db:"name"`
-	Email       string     `db:"email"`
-	Password    string     `db:"password"`
-	Lat         float32    `db:"lat"`
-	Lon         float32    `db:"lon"`
-	CreatedDate time.Time  `db:"created_date,omitempty"`
-	UpdatedDate time.Time  `db:"updated_date,omitempty"`
-	DeletedDate *time.Time `db:"deleted_date,omitempty"`
+	Id           uint64     `db:"id,omitempty"`
+	Name         string     `db:"name"`
+	Email        string     `db:"email"`
+	Password     string     `db:"password"`
+	Role         string     `db:"role"`
+	Lat          float32    `db:"lat"`
+	Lon          float32    `db:"lon"`
+	CreatedDate  time.Time  `db:"created_date,omitempty"`
+	UpdatedDate  time.Time  `db:"updated_date,omitempty"`
+	DeletedDate  *time.Time `db:"deleted_date,omitempty"`
+	VerifiedDate *time.Time `db:"verified_date"`
 }
 
 type UserRepository interface {
 	FindByEmail(email string) (domain.User, error)
 	Save(user domain.User) (domain.User, error)
 	FindById(id uint64) (domain.User, error)
+	FindDeletedByEmail(email string) (domain.User, error)
 	Update(user domain.User) (domain.User, error)
 	Delete(id uint64) error
+	Restore(id uint64, deletedAfter time.Time) error
 	GetCoordinates(user domain.User) (float32, float32, error)
 	SetCoordinates(lat float32, lon float32, user domain.User) error
 	GetUsersIdByArea(points map[string]map[string]float32) []uint64
@@ -64,7 +68,17 @@ func (r userRepository) Save(user domain.User) (domain.User, error) {
 
 func (r userRepository) FindById(id uint64) (domain.User, error) {
 	var u user
-	err := r.coll.Find(db.Cond{"id": id}).One(&u)
+	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).One(&u)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return r.mapModelToDomain(u), nil
+}
+
+// FindDeletedByEmail returns the most recently deleted account with the email which is not purged yet.
+func (r userRepository) FindDeletedByEmail(email string) (domain.User, error) {
+	var u user
+	err := r.coll.Find(db.Cond{"email": email, "deleted_date": db.IsNotNull()}).OrderBy("-deleted_date").One(&u)
 	if err != nil {
 		return domain.User{}, err
 	}
@@ -85,6 +99,15 @@ func (r userRepository) Delete(id uint64) error {
 	return r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": time.Now()})
 }
 
+// Restore un-deletes an account deleted after the given time. Returns db.ErrNoMoreRows
+// when the account is not deleted, was deleted earlier or has been purged.
+func (r userRepository) Restore(id uint64, deletedAfter time.Time) error {
+	return updateOne(r.coll.Session().SQL().
+		Update(UsersTableName).
+		Set("deleted_date", nil, "updated_date", time.Now()).
+		Where(db.Cond{"id": id, "deleted_date >": deletedAfter}))
+}
+
 func (r userRepository) GetCoordinates(user domain.User) (float32, float32, error) {
 	u := r.mapDomainToModel(user)
 	u.UpdatedDate = time.Now()
@@ -119,28 +142,32 @@ func (r userRepository) GetUsersIdByArea(points map[string]map[string]float32) [
 
 func (r userRepository) mapDomainToModel(d domain.User) user {
 	return user{
-		Id:          d.Id,
-		Name:        d.Name,
-		Email:       d.Email,
-		Password:    d.Password,
-		Lat:         d.Lat,
-		Lon:         d.Lon,
-		CreatedDate: d.CreatedDate,
-		UpdatedDate: d.UpdatedDate,
-		DeletedDate: d.DeletedDate,
+		Id:           d.Id,
+		Name:         d.Name,
+		Email:        d.Email,
+		Password:     d.Password,
+		Role:         d.Role,
+		Lat:          d.Lat,
+		Lon:          d.Lon,
+		CreatedDate:  d.CreatedDate,
+		UpdatedDate:  d.UpdatedDate,
+		DeletedDate:  d.DeletedDate,
+		VerifiedDate: d.VerifiedDate,
 	}
 }
 
 func (r userRepository) mapModelToDomain(m user) domain.User {
 	return domain.User{
-		Id:          m.Id,
-		Name:        m.Name,
-		Email:       m.Email,
-		Password:    m.Password,
-		Lat:         m.Lat,
-		Lon:         m.Lon,
-		CreatedDate: m.CreatedDate,
-		UpdatedDate: m.UpdatedDate,
-		DeletedDate: m.DeletedDate,
+		Id:           m.Id,
+		Name:         m.Name,
+		Email:        m.Email,
+		Password:     m.Password,
+		Role:         m.Role,
+		Lat:          m.Lat,
+		Lon:          m.Lon,
+		CreatedDate:  m.CreatedDate,
+		UpdatedDate:  m.UpdatedDate,
+		DeletedDate:  m.DeletedDate,
+		VerifiedDate: m.VerifiedDate,
 	}
 }
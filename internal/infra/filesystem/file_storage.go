@@ -0,0 +1,49 @@
+package filesystem
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+type FileStorageService interface {
+	SaveFile(path string, content []byte) error
+	ReadFile(path string) ([]byte, error)
+	RemoveFile(path string) error
+}
+
+type fileStorageService struct {
+	loc string
+}
+
+func NewFileStorageService(location string) FileStorageService {
+	return fileStorageService{
+		loc: location,
+	}
+}
+
+func (s fileStorageService) SaveFile(path string, content []byte) error {
+	location := filepath.Join(s.loc, filepath.Clean("/"+path))
+	err := os.MkdirAll(filepath.Dir(location), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(location, content, 0644)
+}
+
+func (s fileStorageService) ReadFile(path string) ([]byte, error) {
+	location := filepath.Join(s.loc, filepath.Clean("/"+path))
+	return os.ReadFile(location)
+}
+
+func (s fileStorageService) RemoveFile(path string) error {
+	location := filepath.Join(s.loc, filepath.Clean("/"+path))
+	err := os.Remove(location)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
@@ -8,17 +8,25 @@ import (
 	"errors"
 	"log"
 	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/upper/db/v4"
 )
 
 type AuthController struct {
-	authService app.AuthService
-	userService app.UserService
+	authService              app.AuthService
+	userService              app.UserService
+	emailVerificationService app.EmailVerificationService
+	passwordResetService     app.PasswordResetService
 }
 
-func NewAuthController(as app.AuthService, us app.UserService) AuthController {
+func NewAuthController(as app.AuthService, us app.UserService, evs app.EmailVerificationService, prs app.PasswordResetService) AuthController {
 	return AuthController{
-		authService: as,
-		userService: us,
+		authService:              as,
+		userService:              us,
+		emailVerificationService: evs,
+		passwordResetService:     prs,
 	}
 }
 
@@ -27,11 +35,11 @@ func (c AuthController) Register() http.HandlerFunc {
 		user, err := requests.Bind(r, requests.RegisterRequest{}, domain.User{})
 		if err != nil {
 			log.Printf("AuthController: %s", err)
-			BadRequest(w, errors.New("invalid request body"))
+			BadRequest(w, err)
 			return
 		}
 
-		user, token, err := c.authService.Register(user)
+		user, tokens, err := c.authService.Register(user, requests.DecodeSessionClient(r))
 		if err != nil {
 			log.Printf("AuthController: %s", err)
 			BadRequest(w, err)
@@ -39,7 +47,7 @@ func (c AuthController) Register() http.HandlerFunc {
 		}
 
 		var authDto resources.AuthDto
-		Success(w, authDto.DomainToDto(token, user))
+		Success(w, authDto.DomainToDto(tokens, user))
 	}
 }
 
@@ -52,14 +60,119 @@ func (c AuthController) Login() http.HandlerFunc {
 			return
 		}
 
-		u, token, err := c.authService.Login(user)
+		u, tokens, err := c.authService.Login(user, requests.DecodeSessionClient(r))
+		if err != nil {
+			var tooMany app.TooManyAttemptsError
+			if errors.As(err, &tooMany) {
+				TooManyRequests(w, tooMany.RetryAfter, err)
+				return
+			}
+			if errors.Is(err, app.ErrInvalidCredentials) {
+				Unauthorized(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+
+		if tokens.ChallengeToken != "" {
+			Success(w, resources.TwoFactorChallengeDto{TwoFactorRequired: true, ChallengeToken: tokens.ChallengeToken})
+			return
+		}
+
+		var authDto resources.AuthDto
+		Success(w, authDto.DomainToDto(tokens, u))
+	}
+}
+
+func (c AuthController) Restore() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := requests.Bind(r, requests.AuthRequest{}, domain.User{})
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		u, tokens, err := c.authService.Restore(user, requests.DecodeSessionClient(r))
 		if err != nil {
-			Unauthorized(w, err)
+			var tooMany app.TooManyAttemptsError
+			if errors.As(err, &tooMany) {
+				TooManyRequests(w, tooMany.RetryAfter, err)
+				return
+			}
+			if errors.Is(err, app.ErrInvalidCredentials) {
+				Unauthorized(w, err)
+				return
+			}
+			if errors.Is(err, app.ErrRestoreExpired) {
+				Forbidden(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+
+		if tokens.ChallengeToken != "" {
+			Success(w, resources.TwoFactorChallengeDto{TwoFactorRequired: true, ChallengeToken: tokens.ChallengeToken})
 			return
 		}
 
 		var authDto resources.AuthDto
-		Success(w, authDto.DomainToDto(token, u))
+		Success(w, authDto.DomainToDto(tokens, u))
+	}
+}
+
+func (c AuthController) LoginTwoFactor() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := requests.Bind(r, requests.TwoFactorLoginRequest{}, domain.TwoFactorLogin{})
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		u, tokens, err := c.authService.VerifyTwoFactor(req, requests.DecodeSessionClient(r))
+		if err != nil {
+			var tooMany app.TooManyAttemptsError
+			switch {
+			case errors.As(err, &tooMany):
+				TooManyRequests(w, tooMany.RetryAfter, err)
+			case errors.Is(err, app.ErrInvalidChallenge), errors.Is(err, app.ErrInvalidTwoFactorCode), errors.Is(err, app.ErrTwoFactorNotEnabled):
+				Unauthorized(w, err)
+			default:
+				log.Printf("AuthController: %s", err)
+				InternalServerError(w, err)
+			}
+			return
+		}
+
+		var authDto resources.AuthDto
+		Success(w, authDto.DomainToDto(tokens, u))
+	}
+}
+
+func (c AuthController) Refresh() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		refreshToken, err := requests.Bind(r, requests.RefreshTokenRequest{}, "")
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		u, tokens, err := c.authService.Refresh(refreshToken)
+		if err != nil {
+			if errors.Is(err, app.ErrInvalidRefreshToken) || errors.Is(err, app.ErrRefreshTokenReused) {
+				Unauthorized(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+
+		var authDto resources.AuthDto
+		Success(w, authDto.DomainToDto(tokens, u))
 	}
 }
 
@@ -91,6 +204,11 @@ func (c AuthController) ChangePassword() http.HandlerFunc {
 		err = c.authService.ChangePassword(user, req, sess)
 		if err != nil {
 			log.Printf("AuthController: %s", err)
+			var weak app.WeakPasswordError
+			if errors.As(err, &weak) {
+				BadRequest(w, err)
+				return
+			}
 			InternalServerError(w, err)
 			return
 		}
@@ -98,3 +216,149 @@ func (c AuthController) ChangePassword() http.HandlerFunc {
 		Ok(w)
 	}
 }
+
+func (c AuthController) Sessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := r.Context().Value(SessKey).(domain.Session)
+
+		sessions, err := c.authService.ListSessions(sess.UserId)
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var sessionDto resources.SessionDto
+		Success(w, sessionDto.DomainToDtoCollection(sessions, sess))
+	}
+}
+
+func (c AuthController) RevokeSession() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := r.Context().Value(SessKey).(domain.Session)
+		id, err := uuid.Parse(chi.URLParam(r, "sessionId"))
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			BadRequest(w, errors.New("invalid session id"))
+			return
+		}
+
+		err = c.authService.RevokeSession(domain.Session{UserId: sess.UserId, UUID: id})
+		if err != nil {
+			if errors.Is(err, db.ErrNoMoreRows) {
+				NotFound(w, errors.New("session not found"))
+				return
+			}
+			log.Printf("AuthController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
+
+func (c AuthController) RevokeOtherSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := r.Context().Value(SessKey).(domain.Session)
+
+		err := c.authService.RevokeOtherSessions(sess)
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
+
+func (c AuthController) VerifyEmail() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			BadRequest(w, errors.New("token is required"))
+			return
+		}
+
+		user, err := c.emailVerificationService.Verify(token)
+		if err != nil {
+			if errors.Is(err, app.ErrInvalidVerificationToken) {
+				BadRequest(w, err)
+				return
+			}
+			log.Printf("AuthController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var userDto resources.UserDto
+		Success(w, userDto.DomainToDto(user))
+	}
+}
+
+func (c AuthController) ResendVerification() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+
+		err := c.emailVerificationService.SendVerification(user)
+		if err != nil {
+			if errors.Is(err, app.ErrAlreadyVerified) {
+				Conflict(w, err)
+				return
+			}
+			log.Printf("AuthController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
+
+func (c AuthController) ForgotPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		email, err := requests.Bind(r, requests.ForgotPasswordRequest{}, "")
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		c.passwordResetService.RequestReset(email, requests.DecodeSessionClient(r))
+		Ok(w)
+	}
+}
+
+func (c AuthController) ResetPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := requests.Bind(r, requests.ResetPasswordRequest{}, domain.PasswordReset{})
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		err = c.passwordResetService.Reset(req)
+		if err != nil {
+			var weak app.WeakPasswordError
+			if errors.Is(err, app.ErrInvalidResetToken) || errors.As(err, &weak) {
+				BadRequest(w, err)
+				return
+			}
+			log.Printf("AuthController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
+
+// Jwks publishes the public keys, so other services can verify our tokens.
+func (c AuthController) Jwks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		Success(w, c.authService.PublicKeys())
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 /* should not use built-in type string as key for value;
@@ -21,11 +24,18 @@ type Userable interface {
 }
 
 var (
-	UserKey        = CtxKey{Name: "user"}
-	SessKey        = CtxKey{Name: "sess"}
-	LocationKey    = CtxKey{Name: "location"}
-	GroupKey       = CtxKey{Name: "group"}
-	GroupMemberKey = CtxKey{Name: "groupMember"}
+	UserKey          = CtxKey{Name: "user"}
+	SessKey          = CtxKey{Name: "sess"}
+	LocationKey      = CtxKey{Name: "location"}
+	LocationTypeKey  = CtxKey{Name: "locationType"}
+	LocationPhotoKey = CtxKey{Name: "locationPhoto"}
+	GroupKey         = CtxKey{Name: "group"}
+	GroupMemberKey   = CtxKey{Name: "groupMember"}
+	GroupInviteKey   = CtxKey{Name: "groupInvite"}
+	JoinRequestKey   = CtxKey{Name: "joinRequest"}
+	GroupTransferKey = CtxKey{Name: "groupTransfer"}
+	GroupRoleKey     = CtxKey{Name: "groupRole"}
+	MemberRoleKey    = CtxKey{Name: "memberRole"}
 
 	PathGuid = CtxKey{Name: "guid"}
 )
@@ -55,6 +65,16 @@ func Created(w http.ResponseWriter, body interface{}) {
 	}
 }
 
+func Accepted(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		log.Print(err)
+	}
+}
+
 func noContent(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusNoContent)
@@ -74,6 +94,21 @@ func Forbidden(w http.ResponseWriter, err error) {
 	encodeErrorBody(w, err)
 }
 
+func Conflict(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+
+	encodeErrorBody(w, err)
+}
+
+func TooManyRequests(w http.ResponseWriter, retryAfter time.Duration, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	w.WriteHeader(http.StatusTooManyRequests)
+
+	encodeErrorBody(w, err)
+}
+
 func InternalServerError(w http.ResponseWriter, err error) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusInternalServerError)
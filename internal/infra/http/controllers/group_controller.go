@@ -5,6 +5,7 @@ import (
 	"boilerplate/internal/domain"
 	"boilerplate/internal/infra/http/requests"
 	"boilerplate/internal/infra/http/resources"
+	"errors"
 	"log"
 	"net/http"
 )
@@ -64,6 +65,11 @@ func (c GroupController) Update() http.HandlerFunc {
 func (c GroupController) Detail() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		group := r.Context().Value(GroupKey).(domain.Group)
+		user := r.Context().Value(UserKey).(domain.User)
+		if !c.groupService.CanView(group, user.Id) {
+			Forbidden(w, errors.New("you have no access to this object"))
+			return
+		}
 		var groupDto resources.GroupDto
 		Success(w, groupDto.DomainToDto(group))
 	}
@@ -77,35 +83,51 @@ func (c GroupController) GetList() http.HandlerFunc {
 			InternalServerError(w, err)
 			return
 		}
-		groups, err := c.groupService.GetList(pagination)
+		user := r.Context().Value(UserKey).(domain.User)
+		groups, err := c.groupService.FindByMember(pagination, user.Id)
 		if err != nil {
 			log.Printf("GroupController: %s", err)
 			InternalServerError(w, err)
 			return
 		}
-		Success(w, resources.GroupDto{}.DomainToDtoPaginatedCollection(groups, pagination))
+		Success(w, resources.UserGroupDto{}.DomainToDtoPaginatedCollection(groups, pagination))
 	}
 }
 
-func (c GroupController) Delete() http.HandlerFunc {
+func (c GroupController) Directory() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		groupId := r.Context().Value(GroupKey).(domain.Group).Id
-		err := c.groupService.Delete(groupId)
+		pagination, err := requests.DecodePaginationQuery(r)
 		if err != nil {
 			log.Printf("GroupController: %s", err)
 			InternalServerError(w, err)
 			return
 		}
-
-		Ok(w)
+		title, err := requests.DecodeGroupSearchQuery(r)
+		if err != nil {
+			log.Printf("GroupController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+		groups, err := c.groupService.Search(pagination, title)
+		if err != nil {
+			log.Printf("GroupController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		Success(w, resources.GroupDirectoryDto{}.DomainToDtoPaginatedCollection(groups, pagination))
 	}
 }
 
-func (c GroupController) GetAccessCode() http.HandlerFunc {
+func (c GroupController) Delete() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		group := r.Context().Value(GroupKey).(domain.Group)
-		accessCode := c.groupService.GetAccessCode(group)
+		groupId := r.Context().Value(GroupKey).(domain.Group).Id
+		err := c.groupService.Delete(groupId)
+		if err != nil {
+			log.Printf("GroupController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
 
-		Success(w, map[string]string{"accessCode": accessCode})
+		Ok(w)
 	}
 }
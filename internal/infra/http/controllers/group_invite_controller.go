@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"boilerplate/internal/app"
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/http/requests"
+	"boilerplate/internal/infra/http/resources"
+	"errors"
+	"log"
+	"net/http"
+)
+
+type GroupInviteController struct {
+	groupInviteService app.GroupInviteService
+}
+
+func NewGroupInviteController(gis app.GroupInviteService) GroupInviteController {
+	return GroupInviteController{
+		groupInviteService: gis,
+	}
+}
+
+func (c GroupInviteController) Save() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		invite, err := requests.Bind(r, requests.CreateGroupInviteRequest{}, domain.GroupInvite{})
+		if err != nil {
+			log.Printf("GroupInviteController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+		invite.GroupId = r.Context().Value(GroupKey).(domain.Group).Id
+		invite.UserId = r.Context().Value(UserKey).(domain.User).Id
+
+		invite, err = c.groupInviteService.Save(invite)
+		if err != nil {
+			log.Printf("GroupInviteController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Created(w, resources.GroupInviteDto{}.DomainToDto(invite))
+	}
+}
+
+func (c GroupInviteController) GetList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		group := r.Context().Value(GroupKey).(domain.Group)
+		invites, err := c.groupInviteService.FindByGroupId(group.Id)
+		if err != nil {
+			log.Printf("GroupInviteController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.GroupInviteDto{}.DomainToDtoCollection(invites))
+	}
+}
+
+func (c GroupInviteController) Revoke() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		group := r.Context().Value(GroupKey).(domain.Group)
+		invite := r.Context().Value(GroupInviteKey).(domain.GroupInvite)
+		if invite.GroupId != group.Id {
+			NotFound(w, errors.New("record not found"))
+			return
+		}
+
+		err := c.groupInviteService.Revoke(invite)
+		if err != nil {
+			log.Printf("GroupInviteController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
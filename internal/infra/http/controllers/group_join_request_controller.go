@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"boilerplate/internal/app"
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/http/requests"
+	"boilerplate/internal/infra/http/resources"
+	"errors"
+	"log"
+	"net/http"
+)
+
+type GroupJoinRequestController struct {
+	joinRequestService app.GroupJoinRequestService
+}
+
+func NewGroupJoinRequestController(gjrs app.GroupJoinRequestService) GroupJoinRequestController {
+	return GroupJoinRequestController{
+		joinRequestService: gjrs,
+	}
+}
+
+func (c GroupJoinRequestController) GetList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pagination, err := requests.DecodePaginationQuery(r)
+		if err != nil {
+			log.Printf("GroupJoinRequestController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		group := r.Context().Value(GroupKey).(domain.Group)
+		joinRequests, err := c.joinRequestService.FindPendingByGroupId(pagination, group.Id)
+		if err != nil {
+			log.Printf("GroupJoinRequestController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		Success(w, resources.GroupJoinRequestDto{}.DomainToDtoPaginatedCollection(joinRequests, pagination))
+	}
+}
+
+func (c GroupJoinRequestController) Approve() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		joinRequest, ok := c.groupJoinRequest(w, r)
+		if !ok {
+			return
+		}
+		moderator := r.Context().Value(UserKey).(domain.User)
+
+		member, err := c.joinRequestService.Approve(joinRequest, moderator)
+		if err != nil {
+			if errors.Is(err, app.ErrJoinRequestNotPending) {
+				Conflict(w, err)
+				return
+			}
+			log.Printf("GroupJoinRequestController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		Success(w, resources.GroupMemberDto{}.DomainToDto(member))
+	}
+}
+
+func (c GroupJoinRequestController) Reject() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		joinRequest, ok := c.groupJoinRequest(w, r)
+		if !ok {
+			return
+		}
+		moderator := r.Context().Value(UserKey).(domain.User)
+
+		err := c.joinRequestService.Reject(joinRequest, moderator)
+		if err != nil {
+			if errors.Is(err, app.ErrJoinRequestNotPending) {
+				Conflict(w, err)
+				return
+			}
+			log.Printf("GroupJoinRequestController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		Ok(w)
+	}
+}
+
+func (c GroupJoinRequestController) FindMy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		joinRequests, err := c.joinRequestService.FindPendingByUserId(user.Id)
+		if err != nil {
+			log.Printf("GroupJoinRequestController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		Success(w, resources.GroupJoinRequestDto{}.DomainToDtoCollection(joinRequests))
+	}
+}
+
+func (c GroupJoinRequestController) Cancel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		joinRequest := r.Context().Value(JoinRequestKey).(domain.GroupJoinRequest)
+		err := c.joinRequestService.Cancel(joinRequest)
+		if err != nil {
+			if errors.Is(err, app.ErrJoinRequestNotPending) {
+				Conflict(w, err)
+				return
+			}
+			log.Printf("GroupJoinRequestController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		Ok(w)
+	}
+}
+
+// groupJoinRequest returns the request from the path, checking it belongs to the group from the path.
+func (c GroupJoinRequestController) groupJoinRequest(w http.ResponseWriter, r *http.Request) (domain.GroupJoinRequest, bool) {
+	group := r.Context().Value(GroupKey).(domain.Group)
+	joinRequest := r.Context().Value(JoinRequestKey).(domain.GroupJoinRequest)
+	if joinRequest.GroupId != group.Id {
+		NotFound(w, errors.New("record not found"))
+		return domain.GroupJoinRequest{}, false
+	}
+	return joinRequest, true
+}
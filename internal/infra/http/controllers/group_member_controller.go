@@ -6,6 +6,7 @@ import (
 	"boilerplate/internal/infra/http/requests"
 	"boilerplate/internal/infra/http/resources"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -33,29 +34,44 @@ func (c GroupMemberController) AddGroupMember() http.HandlerFunc {
 		}
 		accessCode := req.AccessCode
 		userId := r.Context().Value(UserKey).(domain.User).Id
-		groupMember, err := c.groupMemberService.AddGroupMember(accessCode, userId)
+		groupMember, joinRequest, err := c.groupMemberService.AddGroupMember(accessCode, userId)
 		if err != nil {
 			log.Printf("GroupMemberController: %s", err)
 			BadRequest(w, err)
 			return
 		}
+		if joinRequest.Id != 0 {
+			Accepted(w, resources.GroupJoinRequestDto{}.DomainToDto(joinRequest))
+			return
+		}
 		var groupMemberDto resources.GroupMemberDto
 		Created(w, groupMemberDto.DomainToDto(groupMember))
 	}
 }
 
-func (c GroupMemberController) ChangeAccessLevel() http.HandlerFunc {
+func (c GroupMemberController) ChangeRole() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		groupMember := r.Context().Value(GroupMemberKey).(domain.GroupMember)
-		req := requests.ChangeMemberAccessLevelRequest{}
+		groupMember, actor, ok := c.groupMember(w, r)
+		if !ok {
+			return
+		}
+		req := requests.ChangeMemberRoleRequest{}
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
 			log.Printf("GroupMemberController: %s", err)
+			BadRequest(w, err)
 			return
 		}
-		newAccessLevel := req.AccessLevel
-		groupMember, err = c.groupMemberService.ChangeAccessLevel(groupMember, newAccessLevel)
+		groupMember, err = c.groupMemberService.ChangeRole(actor, groupMember, req.RoleId)
 		if err != nil {
+			if errors.Is(err, app.ErrRoleNotInGroup) {
+				BadRequest(w, err)
+				return
+			}
+			if errors.Is(err, app.ErrRoleNotCovered) {
+				Forbidden(w, err)
+				return
+			}
 			log.Printf("GroupMemberController: %s", err)
 			InternalServerError(w, err)
 			return
@@ -91,9 +107,35 @@ func (c GroupMemberController) GetMembersList() http.HandlerFunc {
 
 func (c GroupMemberController) DeleteGroupMember() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		groupMemberId := r.Context().Value(GroupMemberKey).(domain.GroupMember).Id
-		err := c.groupMemberService.DeleteGroupMember(groupMemberId)
+		groupMember, actor, ok := c.groupMember(w, r)
+		if !ok {
+			return
+		}
+		err := c.groupMemberService.DeleteGroupMember(actor, groupMember)
 		if err != nil {
+			if errors.Is(err, app.ErrRoleNotCovered) {
+				Forbidden(w, err)
+				return
+			}
+			log.Printf("GroupMemberController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
+
+func (c GroupMemberController) Leave() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		group := r.Context().Value(GroupKey).(domain.Group)
+		user := r.Context().Value(UserKey).(domain.User)
+		err := c.groupMemberService.Leave(group, user)
+		if err != nil {
+			if errors.Is(err, app.ErrOwnerCannotLeave) || errors.Is(err, app.ErrNotGroupMember) {
+				BadRequest(w, err)
+				return
+			}
 			log.Printf("GroupMemberController: %s", err)
 			InternalServerError(w, err)
 			return
@@ -135,3 +177,15 @@ func (c GroupMemberController) FindMembersByArea() http.HandlerFunc {
 		Success(w, resources.GroupMemberDto{}.DomainToDtoPaginatedCollection(groupMembers, pagination))
 	}
 }
+
+// groupMember returns the member from the path, checking it is a current member of the
+// group the actor's role was checked for, together with that role.
+func (c GroupMemberController) groupMember(w http.ResponseWriter, r *http.Request) (domain.GroupMember, domain.GroupRole, bool) {
+	groupMember := r.Context().Value(GroupMemberKey).(domain.GroupMember)
+	actor := r.Context().Value(MemberRoleKey).(domain.GroupRole)
+	if groupMember.GroupId != actor.GroupId || groupMember.DeletedDate != nil {
+		NotFound(w, errors.New("record not found"))
+		return domain.GroupMember{}, domain.GroupRole{}, false
+	}
+	return groupMember, actor, true
+}
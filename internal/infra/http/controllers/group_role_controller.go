@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"boilerplate/internal/app"
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/http/requests"
+	"boilerplate/internal/infra/http/resources"
+	"errors"
+	"log"
+	"net/http"
+)
+
+type GroupRoleController struct {
+	groupRoleService app.GroupRoleService
+}
+
+func NewGroupRoleController(grs app.GroupRoleService) GroupRoleController {
+	return GroupRoleController{
+		groupRoleService: grs,
+	}
+}
+
+func (c GroupRoleController) GetList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		group := r.Context().Value(GroupKey).(domain.Group)
+		roles, err := c.groupRoleService.FindByGroupId(group.Id)
+		if err != nil {
+			log.Printf("GroupRoleController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		Success(w, resources.GroupRoleDto{}.DomainToDtoCollection(roles))
+	}
+}
+
+func (c GroupRoleController) Save() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		role, err := requests.Bind(r, requests.GroupRoleRequest{}, domain.GroupRole{})
+		if err != nil {
+			log.Printf("GroupRoleController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+		role.GroupId = r.Context().Value(GroupKey).(domain.Group).Id
+
+		role, err = c.groupRoleService.Save(role)
+		if err != nil {
+			if errors.Is(err, app.ErrRoleNameTaken) {
+				Conflict(w, err)
+				return
+			}
+			log.Printf("GroupRoleController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		Created(w, resources.GroupRoleDto{}.DomainToDto(role))
+	}
+}
+
+func (c GroupRoleController) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		current, ok := c.groupRole(w, r)
+		if !ok {
+			return
+		}
+		role, err := requests.Bind(r, requests.GroupRoleRequest{}, domain.GroupRole{})
+		if err != nil {
+			log.Printf("GroupRoleController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		role, err = c.groupRoleService.Update(role, current)
+		if err != nil {
+			if errors.Is(err, app.ErrRoleNameTaken) {
+				Conflict(w, err)
+				return
+			}
+			if errors.Is(err, app.ErrDefaultRoleRequired) {
+				BadRequest(w, err)
+				return
+			}
+			log.Printf("GroupRoleController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		Success(w, resources.GroupRoleDto{}.DomainToDto(role))
+	}
+}
+
+func (c GroupRoleController) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		role, ok := c.groupRole(w, r)
+		if !ok {
+			return
+		}
+
+		err := c.groupRoleService.Delete(role)
+		if err != nil {
+			if errors.Is(err, app.ErrRoleInUse) || errors.Is(err, app.ErrDefaultRoleDelete) {
+				Conflict(w, err)
+				return
+			}
+			log.Printf("GroupRoleController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		Ok(w)
+	}
+}
+
+// groupRole returns the role from the path, checking it belongs to the group from the path.
+func (c GroupRoleController) groupRole(w http.ResponseWriter, r *http.Request) (domain.GroupRole, bool) {
+	group := r.Context().Value(GroupKey).(domain.Group)
+	role := r.Context().Value(GroupRoleKey).(domain.GroupRole)
+	if role.GroupId != group.Id {
+		NotFound(w, errors.New("record not found"))
+		return domain.GroupRole{}, false
+	}
+	return role, true
+}
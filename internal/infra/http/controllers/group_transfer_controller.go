@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"boilerplate/internal/app"
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/http/requests"
+	"boilerplate/internal/infra/http/resources"
+	"errors"
+	"log"
+	"net/http"
+)
+
+type GroupTransferController struct {
+	groupTransferService app.GroupTransferService
+}
+
+func NewGroupTransferController(gts app.GroupTransferService) GroupTransferController {
+	return GroupTransferController{
+		groupTransferService: gts,
+	}
+}
+
+func (c GroupTransferController) Save() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		transfer, err := requests.Bind(r, requests.CreateGroupTransferRequest{}, domain.GroupTransfer{})
+		if err != nil {
+			log.Printf("GroupTransferController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+		group := r.Context().Value(GroupKey).(domain.Group)
+
+		transfer, err = c.groupTransferService.Nominate(group, transfer.ToUserId)
+		if err != nil {
+			if errors.Is(err, app.ErrNomineeNotMember) {
+				BadRequest(w, err)
+				return
+			}
+			log.Printf("GroupTransferController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Created(w, resources.GroupTransferDto{}.DomainToDto(transfer))
+	}
+}
+
+func (c GroupTransferController) Detail() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		group := r.Context().Value(GroupKey).(domain.Group)
+		transfer, err := c.groupTransferService.FindPending(group)
+		if err != nil {
+			if errors.Is(err, app.ErrNoPendingTransfer) {
+				NotFound(w, err)
+				return
+			}
+			log.Printf("GroupTransferController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.GroupTransferDto{}.DomainToDto(transfer))
+	}
+}
+
+func (c GroupTransferController) Cancel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		group := r.Context().Value(GroupKey).(domain.Group)
+		err := c.groupTransferService.Cancel(group)
+		if err != nil {
+			if errors.Is(err, app.ErrNoPendingTransfer) {
+				NotFound(w, err)
+				return
+			}
+			log.Printf("GroupTransferController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
+
+func (c GroupTransferController) FindMy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		transfers, err := c.groupTransferService.FindPendingByUserId(user.Id)
+		if err != nil {
+			log.Printf("GroupTransferController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.GroupTransferDto{}.DomainToDtoCollection(transfers))
+	}
+}
+
+func (c GroupTransferController) Accept() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		transfer := r.Context().Value(GroupTransferKey).(domain.GroupTransfer)
+		err := c.groupTransferService.Accept(transfer)
+		if err != nil {
+			if errors.Is(err, app.ErrTransferNotPending) || errors.Is(err, app.ErrTransferNotPossible) {
+				Conflict(w, err)
+				return
+			}
+			log.Printf("GroupTransferController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
+
+func (c GroupTransferController) Decline() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		transfer := r.Context().Value(GroupTransferKey).(domain.GroupTransfer)
+		err := c.groupTransferService.Decline(transfer)
+		if err != nil {
+			if errors.Is(err, app.ErrTransferNotPending) {
+				Conflict(w, err)
+				return
+			}
+			log.Printf("GroupTransferController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
@@ -5,18 +5,25 @@ import (
 	"boilerplate/internal/domain"
 	"boilerplate/internal/infra/http/requests"
 	"boilerplate/internal/infra/http/resources"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
 )
 
 type LocationController struct {
-	locationService app.LocationService
+	locationService      app.LocationService
+	locationPhotoService app.LocationPhotoService
 }
 
-func NewLocationController(ls app.LocationService) LocationController {
+func NewLocationController(ls app.LocationService, lps app.LocationPhotoService) LocationController {
 	return LocationController{
-		locationService: ls,
+		locationService:      ls,
+		locationPhotoService: lps,
 	}
 }
 
@@ -51,9 +58,23 @@ func (c LocationController) Update() http.HandlerFunc {
 		instance := r.Context().Value(LocationKey).(domain.Location)
 		location.UserId = instance.UserId
 		location.Id = instance.Id
-		location, err = c.locationService.Update(location)
+		location.Occupancy = instance.Occupancy
+		userId := r.Context().Value(UserKey).(domain.User).Id
+		location, err = c.locationService.Update(location, userId)
 		if err != nil {
 			log.Printf("LocationController: %s", err)
+			if errors.Is(err, app.ErrLocationNotFound) {
+				NotFound(w, err)
+				return
+			}
+			if errors.Is(err, app.ErrUnknownLocationType) {
+				BadRequest(w, err)
+				return
+			}
+			if errors.Is(err, app.ErrCapacityTooLow) {
+				Conflict(w, err)
+				return
+			}
 			InternalServerError(w, err)
 			return
 		}
@@ -73,7 +94,8 @@ func (c LocationController) Detail() http.HandlerFunc {
 func (c LocationController) Delete() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		locationId := r.Context().Value(LocationKey).(domain.Location).Id
-		err := c.locationService.Delete(locationId)
+		userId := r.Context().Value(UserKey).(domain.User).Id
+		err := c.locationService.Delete(locationId, userId)
 		if err != nil {
 			log.Printf("LocationController: %s", err)
 			InternalServerError(w, err)
@@ -92,16 +114,27 @@ func (c LocationController) FindByArea() http.HandlerFunc {
 			InternalServerError(w, err)
 			return
 		}
-		req := requests.FindByAreaLocationRequest{}
-		err = json.NewDecoder(r.Body).Decode(&req)
+		area, err := requests.Bind(r, requests.FindByAreaLocationRequest{}, domain.LocationSearch{})
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+		points, filter := area.Points, area.Filter
+		filter.ViewerId = r.Context().Value(UserKey).(domain.User).Id
+		format, err := requests.DecodeExportFormat(r)
 		if err != nil {
 			log.Printf("LocationController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+		if format != domain.JsonFormat {
+			export(w, format, func(fn func(domain.Location) error) error {
+				return c.locationService.ExportByArea(points, filter, fn)
+			})
 			return
 		}
-		points := make(map[string]map[string]float32)
-		points["UpperLeftPoint"] = map[string]float32{"lat": req.UpperLeftPoint["lat"], "lon": req.UpperLeftPoint["lon"]}
-		points["BottomRightPoint"] = map[string]float32{"lat": req.BottomRightPoint["lat"], "lon": req.BottomRightPoint["lon"]}
-		locations, err := c.locationService.FindByArea(pagination, points)
+		locations, err := c.locationService.FindByArea(pagination, points, filter)
 		if err != nil {
 			log.Printf("LocationController: %s", err)
 			InternalServerError(w, err)
@@ -120,7 +153,25 @@ func (c LocationController) FindByUserId() http.HandlerFunc {
 			return
 		}
 		userId := r.Context().Value(UserKey).(domain.User).Id
-		locations, err := c.locationService.FindByUserId(pagination, userId)
+		filter, err := requests.DecodeLocationFilterQuery(r)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+		format, err := requests.DecodeExportFormat(r)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+		if format != domain.JsonFormat {
+			export(w, format, func(fn func(domain.Location) error) error {
+				return c.locationService.ExportByUserId(userId, filter, fn)
+			})
+			return
+		}
+		locations, err := c.locationService.FindByUserId(pagination, userId, filter)
 		if err != nil {
 			log.Printf("LocationController: %s", err)
 			InternalServerError(w, err)
@@ -129,3 +180,297 @@ func (c LocationController) FindByUserId() http.HandlerFunc {
 		Success(w, resources.LocationDto{}.DomainToDtoPaginatedCollection(locations, pagination))
 	}
 }
+
+func (c LocationController) FindNearest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		search, err := requests.Bind(r, requests.FindNearestLocationRequest{}, domain.NearestSearch{})
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+		search.ViewerId = r.Context().Value(UserKey).(domain.User).Id
+		locations, err := c.locationService.FindNearest(search)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		Success(w, resources.LocationDto{}.DomainToDtoCollection(locations))
+	}
+}
+
+func (c LocationController) CheckIn() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		location := r.Context().Value(LocationKey).(domain.Location)
+		userId := r.Context().Value(UserKey).(domain.User).Id
+		location, err := c.locationService.CheckIn(location, userId)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			if errors.Is(err, app.ErrLocationFull) || errors.Is(err, app.ErrAlreadyCheckedIn) {
+				Conflict(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+		var locationDto resources.LocationDto
+		Success(w, locationDto.DomainToDto(location))
+	}
+}
+
+func (c LocationController) CheckOut() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		location := r.Context().Value(LocationKey).(domain.Location)
+		userId := r.Context().Value(UserKey).(domain.User).Id
+		location, err := c.locationService.CheckOut(location, userId)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			if errors.Is(err, app.ErrNotCheckedIn) {
+				Conflict(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+		var locationDto resources.LocationDto
+		Success(w, locationDto.DomainToDto(location))
+	}
+}
+
+func (c LocationController) OccupancyHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pagination, err := requests.DecodePaginationQuery(r)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		locationId := r.Context().Value(LocationKey).(domain.Location).Id
+		events, err := c.locationService.GetOccupancyHistory(pagination, locationId)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		Success(w, resources.OccupancyEventDto{}.DomainToDtoPaginatedCollection(events, pagination))
+	}
+}
+
+func (c LocationController) UploadPhoto() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		location := r.Context().Value(LocationKey).(domain.Location)
+		userId := r.Context().Value(UserKey).(domain.User).Id
+
+		r.Body = http.MaxBytesReader(w, r.Body, app.MaxPhotoSize+(1<<20))
+		file, _, err := r.FormFile("photo")
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			BadRequest(w, fmt.Errorf("multipart 'photo' field is required and must be up to %d bytes", app.MaxPhotoSize))
+			return
+		}
+		defer file.Close()
+
+		content, err := io.ReadAll(io.LimitReader(file, app.MaxPhotoSize+1))
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		photo, err := c.locationPhotoService.Upload(location, userId, content)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			if errors.Is(err, app.ErrPhotoTooLarge) || errors.Is(err, app.ErrUnsupportedContentType) {
+				BadRequest(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+		var photoDto resources.LocationPhotoDto
+		Created(w, photoDto.DomainToDto(photo))
+	}
+}
+
+func (c LocationController) Photo() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		location := r.Context().Value(LocationKey).(domain.Location)
+		photo := r.Context().Value(LocationPhotoKey).(domain.LocationPhoto)
+		if photo.LocationId != location.Id {
+			NotFound(w, errors.New("record not found"))
+			return
+		}
+
+		content, err := c.locationPhotoService.Content(photo)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		// visibility depends on the user, so shared caches must not keep the photo
+		w.Header().Set("Content-Type", photo.ContentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Header().Set("Cache-Control", "private, max-age=3600")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(content)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+		}
+	}
+}
+
+func (c LocationController) DeletePhoto() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		location := r.Context().Value(LocationKey).(domain.Location)
+		photo := r.Context().Value(LocationPhotoKey).(domain.LocationPhoto)
+		if photo.LocationId != location.Id {
+			NotFound(w, errors.New("record not found"))
+			return
+		}
+
+		err := c.locationPhotoService.Delete(photo)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
+
+func (c LocationController) Import() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		locationImport, err := requests.DecodeLocationImport(r)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+		userId := r.Context().Value(UserKey).(domain.User).Id
+		locationImport, err = c.locationService.Import(locationImport, userId)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		Success(w, resources.LocationImportDto{}.DomainToDto(locationImport))
+	}
+}
+
+func (c LocationController) Search() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pagination, err := requests.DecodePaginationQuery(r)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		search, err := requests.Bind(r, requests.SearchLocationRequest{}, domain.LocationSearch{})
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+		search.Filter.ViewerId = r.Context().Value(UserKey).(domain.User).Id
+		locations, err := c.locationService.Search(pagination, search)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			if errors.Is(err, app.ErrEmptySearchQuery) {
+				BadRequest(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+		Success(w, resources.LocationDto{}.DomainToDtoPaginatedCollection(locations, pagination))
+	}
+}
+
+func (c LocationController) History() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pagination, err := requests.DecodePaginationQuery(r)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		locationId := r.Context().Value(LocationKey).(domain.Location).Id
+		revisions, err := c.locationService.GetHistory(pagination, locationId)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		Success(w, resources.LocationRevisionDto{}.DomainToDtoPaginatedCollection(revisions, pagination))
+	}
+}
+
+func (c LocationController) Revert() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		revisionId, err := strconv.ParseUint(chi.URLParam(r, "revisionId"), 10, 64)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			BadRequest(w, errors.New("invalid revisionId parameter(only non-negative integers)"))
+			return
+		}
+		location := r.Context().Value(LocationKey).(domain.Location)
+		userId := r.Context().Value(UserKey).(domain.User).Id
+		location, err = c.locationService.Revert(location, revisionId, userId)
+		if err != nil {
+			log.Printf("LocationController: %s", err)
+			if errors.Is(err, app.ErrRevisionNotFound) || errors.Is(err, app.ErrLocationNotFound) {
+				NotFound(w, err)
+				return
+			}
+			if errors.Is(err, app.ErrUnknownLocationType) {
+				BadRequest(w, err)
+				return
+			}
+			if errors.Is(err, app.ErrCapacityTooLow) {
+				Conflict(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+		var locationDto resources.LocationDto
+		Success(w, locationDto.DomainToDto(location))
+	}
+}
+
+// export streams locations in the requested format, flushing the response as it goes.
+func export(w http.ResponseWriter, format string, each func(fn func(domain.Location) error) error) {
+	writer, err := resources.NewLocationStreamWriter(format, w)
+	if err != nil {
+		log.Printf("LocationController: %s", err)
+		BadRequest(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", writer.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", writer.FileName()))
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	count := 0
+	err = writer.Begin()
+	if err == nil {
+		err = each(func(location domain.Location) error {
+			count++
+			if flusher != nil && count%100 == 0 {
+				flusher.Flush()
+			}
+			return writer.Write(location)
+		})
+	}
+	if err == nil {
+		err = writer.End()
+	}
+	if err != nil {
+		// headers are already sent, the client gets a truncated file
+		log.Printf("LocationController: export failed: %s", err)
+	}
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"boilerplate/internal/app"
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/http/requests"
+	"boilerplate/internal/infra/http/resources"
+	"errors"
+	"log"
+	"net/http"
+)
+
+type LocationTypeController struct {
+	locationTypeService app.LocationTypeService
+}
+
+func NewLocationTypeController(lts app.LocationTypeService) LocationTypeController {
+	return LocationTypeController{
+		locationTypeService: lts,
+	}
+}
+
+func (c LocationTypeController) Save() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		locationType, err := requests.Bind(r, requests.LocationTypeRequest{}, domain.LocationType{})
+		if err != nil {
+			log.Printf("LocationTypeController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+		locationType, err = c.locationTypeService.Save(locationType)
+		if err != nil {
+			log.Printf("LocationTypeController: %s", err)
+			if errors.Is(err, app.ErrLocationTypeTaken) {
+				Conflict(w, err)
+				return
+			}
+			BadRequest(w, err)
+			return
+		}
+		var locationTypeDto resources.LocationTypeDto
+		Created(w, locationTypeDto.DomainToDto(locationType))
+	}
+}
+
+func (c LocationTypeController) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		locationType, err := requests.Bind(r, requests.LocationTypeRequest{}, domain.LocationType{})
+		if err != nil {
+			log.Printf("LocationTypeController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+		instance := r.Context().Value(LocationTypeKey).(domain.LocationType)
+		locationType.Id = instance.Id
+		locationType.CreatedDate = instance.CreatedDate
+		locationType, err = c.locationTypeService.Update(locationType)
+		if err != nil {
+			log.Printf("LocationTypeController: %s", err)
+			if errors.Is(err, app.ErrLocationTypeTaken) {
+				Conflict(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+		var locationTypeDto resources.LocationTypeDto
+		Success(w, locationTypeDto.DomainToDto(locationType))
+	}
+}
+
+func (c LocationTypeController) Detail() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		locationType := r.Context().Value(LocationTypeKey).(domain.LocationType)
+		var locationTypeDto resources.LocationTypeDto
+		Success(w, locationTypeDto.DomainToDto(locationType))
+	}
+}
+
+func (c LocationTypeController) GetList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pagination, err := requests.DecodePaginationQuery(r)
+		if err != nil {
+			log.Printf("LocationTypeController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		locationTypes, err := c.locationTypeService.GetList(pagination)
+		if err != nil {
+			log.Printf("LocationTypeController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		Success(w, resources.LocationTypeDto{}.DomainToDtoPaginatedCollection(locationTypes, pagination))
+	}
+}
+
+func (c LocationTypeController) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		locationTypeId := r.Context().Value(LocationTypeKey).(domain.LocationType).Id
+		err := c.locationTypeService.Delete(locationTypeId)
+		if err != nil {
+			log.Printf("LocationTypeController: %s", err)
+			if errors.Is(err, app.ErrLocationTypeInUse) {
+				Conflict(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
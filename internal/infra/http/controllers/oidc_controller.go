@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"boilerplate/internal/app"
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/http/requests"
+	"boilerplate/internal/infra/http/resources"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// oidcBindingCookie ties the callback to the browser that started the login.
+const oidcBindingCookie = "oidc_binding"
+
+type OidcController struct {
+	oidcService app.OidcService
+}
+
+func NewOidcController(os app.OidcService) OidcController {
+	return OidcController{
+		oidcService: os,
+	}
+}
+
+func (c OidcController) Providers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		Success(w, resources.OidcProvidersDto{Providers: c.oidcService.Providers()})
+	}
+}
+
+func (c OidcController) Authorize() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		login, err := c.oidcService.AuthorizationUrl(r.Context(), chi.URLParam(r, "provider"))
+		if err != nil {
+			if errors.Is(err, app.ErrUnknownProvider) {
+				NotFound(w, err)
+				return
+			}
+			log.Printf("OidcController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		// the callback lives under the authorize path, so the cookie goes only there
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcBindingCookie,
+			Value:    login.Binding,
+			Path:     r.URL.Path,
+			MaxAge:   int((10 * time.Minute).Seconds()),
+			Secure:   true,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		Success(w, resources.OidcAuthorizationDto{Url: login.Url})
+	}
+}
+
+func (c OidcController) Callback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if providerErr := q.Get("error"); providerErr != "" {
+			BadRequest(w, fmt.Errorf("identity provider returned an error: %s", providerErr))
+			return
+		}
+		cb := domain.OidcCallback{
+			Provider: chi.URLParam(r, "provider"),
+			Code:     q.Get("code"),
+			State:    q.Get("state"),
+		}
+		if cb.Code == "" || cb.State == "" {
+			BadRequest(w, errors.New("code and state are required"))
+			return
+		}
+		if cookie, err := r.Cookie(oidcBindingCookie); err == nil {
+			cb.Binding = cookie.Value
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcBindingCookie,
+			Path:     strings.TrimSuffix(r.URL.Path, "/callback"),
+			MaxAge:   -1,
+			Secure:   true,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		u, tokens, err := c.oidcService.Callback(r.Context(), cb, requests.DecodeSessionClient(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, app.ErrUnknownProvider):
+				NotFound(w, err)
+			case errors.Is(err, app.ErrInvalidOidcState), errors.Is(err, app.ErrOidcEmailMissing):
+				BadRequest(w, err)
+			case errors.Is(err, app.ErrOidcEmailNotVerified), errors.Is(err, app.ErrOidcAccountDeleted):
+				Conflict(w, err)
+			default:
+				log.Printf("OidcController: %s", err)
+				Unauthorized(w, errors.New("sign in with the identity provider failed"))
+			}
+			return
+		}
+
+		if tokens.ChallengeToken != "" {
+			Success(w, resources.TwoFactorChallengeDto{TwoFactorRequired: true, ChallengeToken: tokens.ChallengeToken})
+			return
+		}
+
+		var authDto resources.AuthDto
+		Success(w, authDto.DomainToDto(tokens, u))
+	}
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"boilerplate/internal/app"
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/http/requests"
+	"boilerplate/internal/infra/http/resources"
+	"errors"
+	"log"
+	"net/http"
+)
+
+type TwoFactorController struct {
+	twoFactorService app.TwoFactorService
+}
+
+func NewTwoFactorController(tfs app.TwoFactorService) TwoFactorController {
+	return TwoFactorController{
+		twoFactorService: tfs,
+	}
+}
+
+func (c TwoFactorController) Status() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+
+		enabled, err := c.twoFactorService.IsEnabled(user.Id)
+		if err != nil {
+			log.Printf("TwoFactorController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.TwoFactorStatusDto{Enabled: enabled})
+	}
+}
+
+func (c TwoFactorController) Enroll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+
+		enrollment, err := c.twoFactorService.Enroll(user)
+		if err != nil {
+			if errors.Is(err, app.ErrTwoFactorEnabled) {
+				Conflict(w, err)
+				return
+			}
+			log.Printf("TwoFactorController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var enrollmentDto resources.TwoFactorEnrollmentDto
+		Success(w, enrollmentDto.DomainToDto(enrollment))
+	}
+}
+
+func (c TwoFactorController) Confirm() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code, err := requests.Bind(r, requests.TwoFactorCodeRequest{}, "")
+		if err != nil {
+			log.Printf("TwoFactorController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+		user := r.Context().Value(UserKey).(domain.User)
+		sess := r.Context().Value(SessKey).(domain.Session)
+
+		codes, err := c.twoFactorService.Confirm(user, code, sess)
+		if err != nil {
+			c.handleError(w, err)
+			return
+		}
+
+		Success(w, resources.RecoveryCodesDto{Codes: codes})
+	}
+}
+
+func (c TwoFactorController) Disable() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code, err := requests.Bind(r, requests.TwoFactorCodeRequest{}, "")
+		if err != nil {
+			log.Printf("TwoFactorController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+		user := r.Context().Value(UserKey).(domain.User)
+
+		err = c.twoFactorService.Disable(user, code)
+		if err != nil {
+			c.handleError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
+
+func (c TwoFactorController) handleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, app.ErrInvalidTwoFactorCode):
+		BadRequest(w, err)
+	case errors.Is(err, app.ErrTwoFactorEnabled), errors.Is(err, app.ErrTwoFactorNotEnabled):
+		Conflict(w, err)
+	default:
+		log.Printf("TwoFactorController: %s", err)
+		InternalServerError(w, err)
+	}
+}
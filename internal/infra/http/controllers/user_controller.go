@@ -6,17 +6,20 @@ import (
 	"boilerplate/internal/infra/http/requests"
 	"boilerplate/internal/infra/http/resources"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 )
 
 type UserController struct {
-	userService app.UserService
+	userService    app.UserService
+	accountService app.AccountService
 }
 
-func NewUserController(us app.UserService) UserController {
+func NewUserController(us app.UserService, as app.AccountService) UserController {
 	return UserController{
-		userService: us,
+		userService:    us,
+		accountService: as,
 	}
 }
 
@@ -73,7 +76,7 @@ func (c UserController) Delete() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		u := r.Context().Value(UserKey).(domain.User)
 
-		err := c.userService.Delete(u.Id)
+		err := c.accountService.Delete(u)
 		if err != nil {
 			log.Printf("UserController: %s", err)
 			InternalServerError(w, err)
@@ -84,6 +87,23 @@ func (c UserController) Delete() http.HandlerFunc {
 	}
 }
 
+func (c UserController) Export() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(UserKey).(domain.User)
+		sess := r.Context().Value(SessKey).(domain.Session)
+
+		export, err := c.accountService.Export(u)
+		if err != nil {
+			log.Printf("UserController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("account-%d.json", u.Id)))
+		Success(w, resources.AccountExportDto{}.DomainToDto(export, sess))
+	}
+}
+
 func (c UserController) GetCoordinates() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		u := r.Context().Value(UserKey).(domain.User)
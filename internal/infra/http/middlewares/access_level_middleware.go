@@ -4,6 +4,7 @@ import (
 	"boilerplate/internal/app"
 	"boilerplate/internal/domain"
 	"boilerplate/internal/infra/http/controllers"
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -17,13 +18,12 @@ type FindableMember interface {
 	FindMember(uint64, uint64) (domain.GroupMember, error)
 }
 
-func CheckRoleMiddleware(accessLevels []domain.AccessLevel, groupService app.GroupService, service FindableMember, groupPathKey string) func(http.Handler) http.Handler {
+// CheckRoleMiddleware lets through the owner of the group and the members whose role has the
+// permission. The role the user acts with is put into the context under controllers.MemberRoleKey.
+func CheckRoleMiddleware(permission domain.Permission, groupService app.GroupService, service FindableMember, groupPathKey string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		hfn := func(w http.ResponseWriter, r *http.Request) {
-			var (
-				err           error
-				accessGranted bool
-			)
+			var err error
 
 			ctx := r.Context()
 			groupId, err := strconv.ParseUint(chi.URLParam(r, groupPathKey), 10, 64)
@@ -44,6 +44,7 @@ func CheckRoleMiddleware(accessLevels []domain.AccessLevel, groupService app.Gro
 			user := ctx.Value(controllers.UserKey).(domain.User)
 			grpDomain := grp.(domain.Group)
 			if grpDomain.UserId == user.Id {
+				ctx = context.WithValue(ctx, controllers.MemberRoleKey, domain.OwnerRole(grpDomain.Id))
 				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
@@ -56,19 +57,13 @@ func CheckRoleMiddleware(accessLevels []domain.AccessLevel, groupService app.Gro
 				return
 			}
 
-			for _, accessLevel := range accessLevels {
-				if member.AccessLevel == accessLevel.GetRole() {
-					accessGranted = true
-					break
-				}
-			}
-
-			if !accessGranted {
-				err = errors.New("access denied. You have no access level to this route")
+			if !member.Role.Can(permission) {
+				err = fmt.Errorf("access denied. Your role has no %s permission", permission)
 				controllers.Forbidden(w, err)
 				return
 			}
 
+			ctx = context.WithValue(ctx, controllers.MemberRoleKey, member.Role)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		}
 		return http.HandlerFunc(hfn)
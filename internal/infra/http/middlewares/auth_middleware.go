@@ -2,46 +2,55 @@ package middlewares
 
 import (
 	"boilerplate/internal/app"
-	"boilerplate/internal/domain"
 	"boilerplate/internal/infra/http/controllers"
+	"boilerplate/internal/infra/http/requests"
+	"boilerplate/internal/infra/jwtkeys"
 	"context"
 	"errors"
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/google/uuid"
-	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/upper/db/v4"
 	"net/http"
 )
 
-func AuthMiddleware(ja *jwtauth.JWTAuth, as app.AuthService, us app.UserService) func(http.Handler) http.Handler {
+func AuthMiddleware(keys jwtkeys.Keys, as app.AuthService, us app.UserService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		hfn := func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
-			token, err := jwtauth.VerifyRequest(ja, r, jwtauth.TokenFromHeader)
-
-			if err != nil {
-				controllers.Unauthorized(w, err)
+			tokenString := jwtauth.TokenFromHeader(r)
+			if tokenString == "" {
+				controllers.Unauthorized(w, jwtauth.ErrNoTokenFound)
 				return
 			}
 
-			if token == nil || jwt.Validate(token) != nil {
-				controllers.Unauthorized(w, err)
+			token, err := keys.Verify(tokenString)
+			if err != nil {
+				controllers.Unauthorized(w, jwtauth.ErrUnauthorized)
 				return
 			}
 
 			claims := token.PrivateClaims()
-			uId := uint64(claims["user_id"].(float64))
-			uUuid, err := uuid.Parse(claims["uuid"].(string))
+			userIdClaim, ok := claims["user_id"].(float64)
+			if !ok {
+				controllers.Unauthorized(w, jwtauth.ErrUnauthorized)
+				return
+			}
+			uuidClaim, ok := claims["uuid"].(string)
+			if !ok {
+				controllers.Unauthorized(w, jwtauth.ErrUnauthorized)
+				return
+			}
+			uId := uint64(userIdClaim)
+			uUuid, err := uuid.Parse(uuidClaim)
 			if err != nil {
 				controllers.Unauthorized(w, err)
 				return
 			}
 
-			auth := domain.Session{
-				UserId: uId,
-				UUID:   uUuid,
-			}
-			err = as.Check(auth)
+			auth := requests.DecodeSessionClient(r)
+			auth.UserId = uId
+			auth.UUID = uUuid
+			auth, err = as.Check(auth)
 			if err != nil {
 				controllers.Unauthorized(w, err)
 				return
@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/http/controllers"
+	"errors"
+	"net/http"
+)
+
+func IsAdminMiddleware(next http.Handler) http.Handler {
+	hfn := func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(controllers.UserKey).(domain.User)
+		if !user.IsAdmin() {
+			err := errors.New("you have no access to this route")
+			controllers.Forbidden(w, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(hfn)
+}
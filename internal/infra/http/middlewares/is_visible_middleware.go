@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/http/controllers"
+	"errors"
+	"net/http"
+)
+
+type Viewable interface {
+	CanView(location domain.Location, userId uint64) bool
+}
+
+func IsVisibleMiddleware(service Viewable, key controllers.CtxKey) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		hfn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			user := ctx.Value(controllers.UserKey).(domain.User)
+			location := ctx.Value(key).(domain.Location)
+
+			if !service.CanView(location, user.Id) {
+				err := errors.New("you have no access to this object")
+				controllers.Forbidden(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(hfn)
+	}
+}
@@ -0,0 +1,18 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// RealIpMiddleware takes the client address from the forwarding headers when the app runs
+// behind a trusted proxy. Without one the headers are set by the client, so they are ignored.
+func RealIpMiddleware(trustProxy bool) func(http.Handler) http.Handler {
+	if trustProxy {
+		return middleware.RealIP
+	}
+	return func(next http.Handler) http.Handler {
+		return next
+	}
+}
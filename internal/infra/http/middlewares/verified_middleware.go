@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"boilerplate/internal/domain"
+	"boilerplate/internal/infra/http/controllers"
+	"errors"
+	"net/http"
+)
+
+// VerifiedMiddleware rejects users with an unconfirmed email when the restricted mode is enabled.
+func VerifiedMiddleware(restrict bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		hfn := func(w http.ResponseWriter, r *http.Request) {
+			user := r.Context().Value(controllers.UserKey).(domain.User)
+			if restrict && !user.IsVerified() {
+				controllers.Forbidden(w, errors.New("please verify your email first"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(hfn)
+	}
+}
@@ -0,0 +1,30 @@
+package requests
+
+import (
+	"boilerplate/internal/domain"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DecodeExportFormat picks the response format from the 'format' query parameter,
+// falling back to the Accept header.
+func DecodeExportFormat(r *http.Request) (string, error) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	switch format {
+	case domain.JsonFormat, domain.GeoJsonFormat, domain.GpxFormat:
+		return format, nil
+	case "":
+	default:
+		return "", fmt.Errorf("problems in parsing 'format' query parameter, use json, geojson or gpx")
+	}
+
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "application/geo+json"):
+		return domain.GeoJsonFormat, nil
+	case strings.Contains(accept, "application/gpx+xml"):
+		return domain.GpxFormat, nil
+	}
+	return domain.JsonFormat, nil
+}
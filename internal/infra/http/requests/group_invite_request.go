@@ -0,0 +1,23 @@
+package requests
+
+import (
+	"boilerplate/internal/domain"
+	"errors"
+	"time"
+)
+
+type CreateGroupInviteRequest struct {
+	MaxUses     uint64     `json:"max_uses" validate:"max=100000"`
+	ExpiresDate *time.Time `json:"expires_date"`
+}
+
+func (r CreateGroupInviteRequest) ToDomainModel() (interface{}, error) {
+	if r.ExpiresDate != nil && !r.ExpiresDate.After(time.Now()) {
+		return nil, errors.New("expires_date must be in the future")
+	}
+
+	return domain.GroupInvite{
+		MaxUses:     r.MaxUses,
+		ExpiresDate: r.ExpiresDate,
+	}, nil
+}
@@ -4,8 +4,8 @@ type AddGroupMemberRequest struct {
 	AccessCode string `json:"access_code" validate:"required"`
 }
 
-type ChangeMemberAccessLevelRequest struct {
-	AccessLevel string `json:"access_level" validate:"required"`
+type ChangeMemberRoleRequest struct {
+	RoleId uint64 `json:"role_id" validate:"required"`
 }
 
 type FindMembersByAreaRequest struct {
@@ -2,28 +2,51 @@ package requests
 
 import (
 	"boilerplate/internal/domain"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
 )
 
+const maxGroupSearchLength = 100
+
 type CreateGroupRequest struct {
-	Title       string `json:"title" validate:"required"`
-	Description string `json:"description" validate:"required"`
+	Title            string `json:"title" validate:"required"`
+	Description      string `json:"description" validate:"required"`
+	RequiresApproval bool   `json:"requires_approval"`
+	Discoverable     bool   `json:"discoverable"`
 }
 
 type UpdateGroupRequest struct {
-	Title       string `json:"title" validate:"required"`
-	Description string `json:"description" validate:"required"`
+	Title            string `json:"title" validate:"required"`
+	Description      string `json:"description" validate:"required"`
+	RequiresApproval bool   `json:"requires_approval"`
+	Discoverable     bool   `json:"discoverable"`
 }
 
 func (r CreateGroupRequest) ToDomainModel() (interface{}, error) {
 	return domain.Group{
-		Title:       r.Title,
-		Description: r.Description,
+		Title:            r.Title,
+		Description:      r.Description,
+		RequiresApproval: r.RequiresApproval,
+		Discoverable:     r.Discoverable,
 	}, nil
 }
 
 func (r UpdateGroupRequest) ToDomainModel() (interface{}, error) {
 	return domain.Group{
-		Title:       r.Title,
-		Description: r.Description,
+		Title:            r.Title,
+		Description:      r.Description,
+		RequiresApproval: r.RequiresApproval,
+		Discoverable:     r.Discoverable,
 	}, nil
 }
+
+// DecodeGroupSearchQuery reads the title to look for in the group directory from the 'title' query parameter.
+func DecodeGroupSearchQuery(r *http.Request) (string, error) {
+	title := strings.TrimSpace(r.URL.Query().Get("title"))
+	if utf8.RuneCountInString(title) > maxGroupSearchLength {
+		return "", fmt.Errorf("'title' query parameter must be at most %d characters long", maxGroupSearchLength)
+	}
+	return title, nil
+}
@@ -0,0 +1,37 @@
+package requests
+
+import (
+	"boilerplate/internal/domain"
+	"fmt"
+)
+
+type GroupRoleRequest struct {
+	Name        string   `json:"name" validate:"required,max=50"`
+	Permissions []string `json:"permissions" validate:"max=20"`
+	IsDefault   bool     `json:"is_default"`
+}
+
+func (r GroupRoleRequest) ToDomainModel() (interface{}, error) {
+	if r.Name == domain.GroupOwnerRole {
+		return nil, fmt.Errorf("%s is reserved for the owner of the group", domain.GroupOwnerRole)
+	}
+
+	permissions := make([]domain.Permission, 0, len(r.Permissions))
+	seen := make(map[domain.Permission]bool)
+	for _, p := range r.Permissions {
+		permission := domain.Permission(p)
+		if !permission.IsValid() {
+			return nil, fmt.Errorf("%s is not a permission", p)
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+
+	return domain.GroupRole{
+		Name:        r.Name,
+		Permissions: permissions,
+		IsDefault:   r.IsDefault,
+	}, nil
+}
@@ -0,0 +1,15 @@
+package requests
+
+import (
+	"boilerplate/internal/domain"
+)
+
+type CreateGroupTransferRequest struct {
+	UserId uint64 `json:"user_id" validate:"required"`
+}
+
+func (r CreateGroupTransferRequest) ToDomainModel() (interface{}, error) {
+	return domain.GroupTransfer{
+		ToUserId: r.UserId,
+	}, nil
+}
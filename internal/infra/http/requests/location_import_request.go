@@ -0,0 +1,199 @@
+package requests
+
+import (
+	"boilerplate/internal/domain"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	MaxImportSize = 10 << 20
+	MaxImportRows = 5000
+)
+
+type geoJsonFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJsonFeature `json:"features"`
+}
+
+type geoJsonFeature struct {
+	Type     string          `json:"type"`
+	Geometry geoJsonGeometry `json:"geometry"`
+	// Properties use the same field names as CreateLocationRequest.
+	Properties json.RawMessage `json:"properties"`
+}
+
+type geoJsonGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// DecodeLocationImport reads a GeoJSON FeatureCollection or a CSV file with
+// lat, lon, title, address, type and description columns and validates every
+// record with the CreateLocationRequest rules.
+func DecodeLocationImport(r *http.Request) (domain.LocationImport, error) {
+	result := domain.LocationImport{}
+	dryRunStr := r.URL.Query().Get("dry_run")
+	if dryRunStr != "" {
+		dryRun, err := strconv.ParseBool(dryRunStr)
+		if err != nil {
+			log.Print(err)
+			return domain.LocationImport{}, fmt.Errorf("problems in parsing 'dry_run' query parameter")
+		}
+		result.DryRun = dryRun
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxImportSize+1))
+	if err != nil {
+		return domain.LocationImport{}, err
+	}
+	if len(body) > MaxImportSize {
+		return domain.LocationImport{}, fmt.Errorf("import file is larger than %d bytes", MaxImportSize)
+	}
+
+	var reqs []importRecord
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		reqs, err = decodeCsvImport(body)
+	case "application/geo+json", "application/json":
+		reqs, err = decodeGeoJsonImport(body)
+	default:
+		err = errors.New("unsupported content type, use text/csv or application/geo+json")
+	}
+	if err != nil {
+		return domain.LocationImport{}, err
+	}
+	if len(reqs) > MaxImportRows {
+		return domain.LocationImport{}, fmt.Errorf("import is limited to %d rows", MaxImportRows)
+	}
+
+	result.Rows = make([]domain.LocationImportRow, len(reqs))
+	for i, rec := range reqs {
+		row := domain.LocationImportRow{Row: i + 1, Err: rec.err}
+		if row.Err == nil {
+			row.Err = v.Struct(rec.req)
+		}
+		if row.Err == nil {
+			var d interface{}
+			d, row.Err = rec.req.ToDomainModel()
+			if row.Err == nil {
+				row.Location = d.(domain.Location)
+			}
+		}
+		result.Rows[i] = row
+	}
+
+	return result, nil
+}
+
+type importRecord struct {
+	req CreateLocationRequest
+	err error
+}
+
+func decodeGeoJsonImport(body []byte) ([]importRecord, error) {
+	var collection geoJsonFeatureCollection
+	err := json.Unmarshal(body, &collection)
+	if err != nil {
+		return nil, err
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, errors.New("GeoJSON import must be a FeatureCollection")
+	}
+
+	records := make([]importRecord, len(collection.Features))
+	for i, feature := range collection.Features {
+		if feature.Geometry.Type != "Point" || len(feature.Geometry.Coordinates) < 2 {
+			records[i].err = errors.New("geometry must be a Point")
+			continue
+		}
+		if len(feature.Properties) > 0 && string(feature.Properties) != "null" {
+			err = json.Unmarshal(feature.Properties, &records[i].req)
+			if err != nil {
+				records[i].err = err
+				continue
+			}
+		}
+		records[i].req.Lon = feature.Geometry.Coordinates[0]
+		records[i].req.Lat = feature.Geometry.Coordinates[1]
+	}
+
+	return records, nil
+}
+
+func decodeCsvImport(body []byte) ([]importRecord, error) {
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("CSV header is required: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"lat", "lon", "title", "address", "type"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV column '%s' is missing", name)
+		}
+	}
+
+	var records []importRecord
+	for {
+		line, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			records = append(records, importRecord{err: err})
+			continue
+		}
+
+		get := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(line) {
+				return ""
+			}
+			return strings.TrimSpace(line[i])
+		}
+
+		var rec importRecord
+		rec.req = CreateLocationRequest{
+			Type:        get("type"),
+			Address:     get("address"),
+			Title:       get("title"),
+			Description: get("description"),
+			Visibility:  get("visibility"),
+		}
+		rec.req.Lat, rec.err = parseCsvFloat(get("lat"), "lat")
+		if rec.err == nil {
+			rec.req.Lon, rec.err = parseCsvFloat(get("lon"), "lon")
+		}
+		if rec.err == nil && get("capacity") != "" {
+			rec.req.Capacity, rec.err = strconv.ParseUint(get("capacity"), 10, 64)
+		}
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+func parseCsvFloat(value string, column string) (float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid '%s' value", column)
+	}
+	return f, nil
+}
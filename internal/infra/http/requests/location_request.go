@@ -2,49 +2,190 @@ package requests
 
 import (
 	"boilerplate/internal/domain"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type CreateLocationRequest struct {
-	Type        string  `json:"type" validate:"required"`
-	Address     string  `json:"address" validate:"required"`
-	Title       string  `json:"title" validate:"required"`
-	Description string  `json:"description" validate:"required"`
-	Lat         float64 `json:"lat" validate:"required"`
-	Lon         float64 `json:"lon" validate:"required"`
+	Type         string              `json:"type" validate:"required"`
+	Address      string              `json:"address" validate:"required"`
+	Title        string              `json:"title" validate:"required"`
+	Description  string              `json:"description" validate:"required"`
+	Lat          float64             `json:"lat" validate:"required"`
+	Lon          float64             `json:"lon" validate:"required"`
+	Capacity     uint64              `json:"capacity" validate:"omitempty,max=100000"`
+	OpeningHours OpeningHoursRequest `json:"opening_hours"`
+	Attributes   []string            `json:"attributes" validate:"unique,dive,oneof=wheelchair_accessible water power wifi pet_friendly"`
+	Visibility   string              `json:"visibility" validate:"omitempty,oneof=private groups public"`
+	GroupIds     []uint64            `json:"group_ids" validate:"required_if=Visibility groups,dive,gt=0"`
 }
 
 type UpdateLocationRequest struct {
-	Type        string  `json:"type" validate:"required"`
-	Address     string  `json:"address" validate:"required"`
-	Title       string  `json:"title" validate:"required"`
-	Description string  `json:"description" validate:"required"`
-	Lat         float64 `json:"lat" validate:"required"`
-	Lon         float64 `json:"lon" validate:"required"`
+	Type         string              `json:"type" validate:"required"`
+	Address      string              `json:"address" validate:"required"`
+	Title        string              `json:"title" validate:"required"`
+	Description  string              `json:"description" validate:"required"`
+	Lat          float64             `json:"lat" validate:"required"`
+	Lon          float64             `json:"lon" validate:"required"`
+	Capacity     uint64              `json:"capacity" validate:"omitempty,max=100000"`
+	OpeningHours OpeningHoursRequest `json:"opening_hours"`
+	Attributes   []string            `json:"attributes" validate:"unique,dive,oneof=wheelchair_accessible water power wifi pet_friendly"`
+	Visibility   string              `json:"visibility" validate:"omitempty,oneof=private groups public"`
+	GroupIds     []uint64            `json:"group_ids" validate:"required_if=Visibility groups,dive,gt=0"`
+}
+
+type OpeningHoursRequest struct {
+	AlwaysOpen bool                   `json:"always_open"`
+	Periods    []OpeningPeriodRequest `json:"periods" validate:"dive"`
+}
+
+type OpeningPeriodRequest struct {
+	Weekday int    `json:"weekday" validate:"min=0,max=6"`
+	Opens   string `json:"opens" validate:"required,datetime=15:04"`
+	Closes  string `json:"closes" validate:"required,datetime=15:04"`
 }
 
 type FindByAreaLocationRequest struct {
 	UpperLeftPoint   map[string]float32 `json:"upper_left_point" validate:"required"`
 	BottomRightPoint map[string]float32 `json:"bottom_right_point" validate:"required"`
+	Types            []string           `json:"types" validate:"max=20,dive,required,max=50"`
+	Attributes       []string           `json:"attributes" validate:"unique,dive,oneof=wheelchair_accessible water power wifi pet_friendly"`
+	OpenNow          bool               `json:"open_now"`
+}
+
+type SearchLocationRequest struct {
+	Query            string             `json:"query" validate:"required,max=200"`
+	UpperLeftPoint   map[string]float32 `json:"upper_left_point" validate:"required_with=BottomRightPoint"`
+	BottomRightPoint map[string]float32 `json:"bottom_right_point" validate:"required_with=UpperLeftPoint"`
+	Types            []string           `json:"types" validate:"max=20,dive,required,max=50"`
+	Attributes       []string           `json:"attributes" validate:"unique,dive,oneof=wheelchair_accessible water power wifi pet_friendly"`
+	OpenNow          bool               `json:"open_now"`
+}
+
+type FindNearestLocationRequest struct {
+	Lat    float64 `json:"lat" validate:"required,latitude"`
+	Lon    float64 `json:"lon" validate:"required,longitude"`
+	Radius float64 `json:"radius" validate:"required,gt=0,max=100000"`
+	Limit  uint64  `json:"limit" validate:"omitempty,max=100"`
 }
 
 func (r CreateLocationRequest) ToDomainModel() (interface{}, error) {
 	return domain.Location{
-		Type:        r.Type,
-		Address:     r.Address,
-		Title:       r.Title,
-		Description: r.Description,
-		Lat:         r.Lat,
-		Lon:         r.Lon,
+		Type:         r.Type,
+		Address:      r.Address,
+		Title:        r.Title,
+		Description:  r.Description,
+		Lat:          r.Lat,
+		Lon:          r.Lon,
+		Capacity:     r.Capacity,
+		OpeningHours: r.OpeningHours.ToDomainModel(),
+		Attributes:   r.Attributes,
+		Visibility:   r.Visibility,
+		GroupIds:     r.GroupIds,
 	}, nil
 }
 
 func (r UpdateLocationRequest) ToDomainModel() (interface{}, error) {
 	return domain.Location{
-		Type:        r.Type,
-		Address:     r.Address,
-		Title:       r.Title,
-		Description: r.Description,
-		Lat:         r.Lat,
-		Lon:         r.Lon,
+		Type:         r.Type,
+		Address:      r.Address,
+		Title:        r.Title,
+		Description:  r.Description,
+		Lat:          r.Lat,
+		Lon:          r.Lon,
+		Capacity:     r.Capacity,
+		OpeningHours: r.OpeningHours.ToDomainModel(),
+		Attributes:   r.Attributes,
+		Visibility:   r.Visibility,
+		GroupIds:     r.GroupIds,
+	}, nil
+}
+
+func (r FindNearestLocationRequest) ToDomainModel() (interface{}, error) {
+	limit := r.Limit
+	if limit == 0 {
+		limit = 20
+	}
+	return domain.NearestSearch{
+		Lat:    r.Lat,
+		Lon:    r.Lon,
+		Radius: r.Radius,
+		Limit:  limit,
 	}, nil
 }
+
+// ToDomainModel gives a search without text, only the area and the filter are used.
+func (r FindByAreaLocationRequest) ToDomainModel() (interface{}, error) {
+	return domain.LocationSearch{
+		Points: map[string]map[string]float32{
+			"UpperLeftPoint":   {"lat": r.UpperLeftPoint["lat"], "lon": r.UpperLeftPoint["lon"]},
+			"BottomRightPoint": {"lat": r.BottomRightPoint["lat"], "lon": r.BottomRightPoint["lon"]},
+		},
+		Filter: domain.LocationFilter{
+			Types:      r.Types,
+			Attributes: r.Attributes,
+			OpenNow:    r.OpenNow,
+		},
+	}, nil
+}
+
+func (r OpeningHoursRequest) ToDomainModel() domain.OpeningHours {
+	periods := make([]domain.OpeningPeriod, len(r.Periods))
+	for i, p := range r.Periods {
+		periods[i] = domain.OpeningPeriod{
+			Weekday: time.Weekday(p.Weekday),
+			Opens:   p.Opens,
+			Closes:  p.Closes,
+		}
+	}
+	return domain.OpeningHours{
+		AlwaysOpen: r.AlwaysOpen,
+		Periods:    periods,
+	}
+}
+
+// DecodeLocationFilterQuery reads the location list filters from the query string,
+// e.g. ?types=shelter,hospital&attributes=water,power&open_now=true
+func DecodeLocationFilterQuery(r *http.Request) (domain.LocationFilter, error) {
+	var filter domain.LocationFilter
+	typesStr := r.URL.Query().Get("types")
+	if typesStr != "" {
+		filter.Types = strings.Split(typesStr, ",")
+	}
+	attributesStr := r.URL.Query().Get("attributes")
+	if attributesStr != "" {
+		filter.Attributes = strings.Split(attributesStr, ",")
+	}
+	openNowStr := r.URL.Query().Get("open_now")
+	if openNowStr != "" {
+		openNow, err := strconv.ParseBool(openNowStr)
+		if err != nil {
+			log.Print(err)
+			return domain.LocationFilter{}, fmt.Errorf("problems in parsing 'open_now' query parameter")
+		}
+		filter.OpenNow = openNow
+	}
+	return filter, nil
+}
+
+func (r SearchLocationRequest) ToDomainModel() (interface{}, error) {
+	search := domain.LocationSearch{
+		Text: r.Query,
+		Filter: domain.LocationFilter{
+			Types:      r.Types,
+			Attributes: r.Attributes,
+			OpenNow:    r.OpenNow,
+		},
+	}
+	if r.UpperLeftPoint != nil && r.BottomRightPoint != nil {
+		search.Points = map[string]map[string]float32{
+			"UpperLeftPoint":   {"lat": r.UpperLeftPoint["lat"], "lon": r.UpperLeftPoint["lon"]},
+			"BottomRightPoint": {"lat": r.BottomRightPoint["lat"], "lon": r.BottomRightPoint["lon"]},
+		}
+	}
+	return search, nil
+}
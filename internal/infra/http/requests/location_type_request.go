@@ -0,0 +1,17 @@
+package requests
+
+import (
+	"boilerplate/internal/domain"
+)
+
+type LocationTypeRequest struct {
+	Name  string `json:"name" validate:"required,max=50"`
+	Title string `json:"title" validate:"required,max=100"`
+}
+
+func (r LocationTypeRequest) ToDomainModel() (interface{}, error) {
+	return domain.LocationType{
+		Name:  r.Name,
+		Title: r.Title,
+	}, nil
+}
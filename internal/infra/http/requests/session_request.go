@@ -0,0 +1,28 @@
+package requests
+
+import (
+	"boilerplate/internal/domain"
+	"net"
+	"net/http"
+)
+
+const maxUserAgentLength = 255
+
+// DecodeSessionClient collects the client details stored against a session. Behind a trusted
+// proxy RemoteAddr already holds the forwarded client address, see RealIpMiddleware.
+func DecodeSessionClient(r *http.Request) domain.Session {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	return domain.Session{
+		UserAgent: userAgent,
+		Ip:        ip,
+	}
+}
@@ -7,12 +7,36 @@ import (
 type RegisterRequest struct {
 	Name     string `json:"name" validate:"required,gte=1,max=40"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,alphanum,gte=4,max=20"`
+	Password string `json:"password" validate:"required"`
 }
 
+// AuthRequest caps the password at the longest one the policy allows, so oversized
+// bodies don't reach the password hasher.
 type AuthRequest struct {
 	Email    string `json:"email"  validate:"required,email"`
-	Password string `json:"password" validate:"required,alphanum,gte=4"`
+	Password string `json:"password" validate:"required,max=128"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,max=20"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required,max=20"`
 }
 
 type UpdateUserRequest struct {
@@ -23,8 +47,8 @@ type UpdateUserRequest struct {
 }
 
 type ChangePasswordRequest struct {
-	OldPassword string `json:"oldPassword" validate:"required,alphanum,gte=4"`
-	NewPassword string `json:"newPassword" validate:"required,alphanum,gte=4"`
+	OldPassword string `json:"oldPassword" validate:"required,max=128"`
+	NewPassword string `json:"newPassword" validate:"required"`
 }
 
 type SetCoordinatesRequest struct {
@@ -53,6 +77,32 @@ func (r AuthRequest) ToDomainModel() (interface{}, error) {
 	}, nil
 }
 
+func (r RefreshTokenRequest) ToDomainModel() (interface{}, error) {
+	return r.RefreshToken, nil
+}
+
+func (r ForgotPasswordRequest) ToDomainModel() (interface{}, error) {
+	return r.Email, nil
+}
+
+func (r ResetPasswordRequest) ToDomainModel() (interface{}, error) {
+	return domain.PasswordReset{
+		Token:       r.Token,
+		NewPassword: r.NewPassword,
+	}, nil
+}
+
+func (r TwoFactorCodeRequest) ToDomainModel() (interface{}, error) {
+	return r.Code, nil
+}
+
+func (r TwoFactorLoginRequest) ToDomainModel() (interface{}, error) {
+	return domain.TwoFactorLogin{
+		ChallengeToken: r.ChallengeToken,
+		Code:           r.Code,
+	}, nil
+}
+
 func (r ChangePasswordRequest) ToDomainModel() (interface{}, error) {
 	return domain.ChangePassword{
 		OldPassword: r.OldPassword,
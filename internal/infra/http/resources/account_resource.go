@@ -0,0 +1,77 @@
+package resources
+
+import (
+	"boilerplate/internal/domain"
+	"time"
+)
+
+type AccountExportDto struct {
+	User            AccountUserDto      `json:"user"`
+	Sessions        []SessionDto        `json:"sessions"`
+	Identities      []UserIdentityDto   `json:"identities"`
+	Locations       []LocationDto       `json:"locations"`
+	Groups          []GroupDto          `json:"groups"`
+	Memberships     []GroupMemberDto    `json:"memberships"`
+	OccupancyEvents []OccupancyEventDto `json:"occupancy_events"`
+	GeneratedDate   time.Time           `json:"generated_date"`
+}
+
+// AccountUserDto is the full user record, UserDto leaves out what the clients don't need.
+type AccountUserDto struct {
+	UserDto
+	Lat          float32    `json:"lat"`
+	Lon          float32    `json:"lon"`
+	CreatedDate  time.Time  `json:"created_date"`
+	UpdatedDate  time.Time  `json:"updated_date"`
+	VerifiedDate *time.Time `json:"verified_date"`
+}
+
+type UserIdentityDto struct {
+	Provider    string    `json:"provider"`
+	Subject     string    `json:"subject"`
+	Email       string    `json:"email"`
+	CreatedDate time.Time `json:"created_date"`
+}
+
+func (d AccountExportDto) DomainToDto(export domain.AccountExport, current domain.Session) AccountExportDto {
+	result := AccountExportDto{
+		User: AccountUserDto{
+			UserDto:      UserDto{}.DomainToDto(export.User),
+			Lat:          export.User.Lat,
+			Lon:          export.User.Lon,
+			CreatedDate:  export.User.CreatedDate,
+			UpdatedDate:  export.User.UpdatedDate,
+			VerifiedDate: export.User.VerifiedDate,
+		},
+		Sessions:        SessionDto{}.DomainToDtoCollection(export.Sessions, current).Items,
+		Identities:      make([]UserIdentityDto, len(export.Identities)),
+		Locations:       make([]LocationDto, len(export.Locations)),
+		Groups:          make([]GroupDto, len(export.Groups)),
+		Memberships:     make([]GroupMemberDto, len(export.Memberships)),
+		OccupancyEvents: make([]OccupancyEventDto, len(export.OccupancyEvents)),
+		GeneratedDate:   export.GeneratedDate,
+	}
+
+	for i, identity := range export.Identities {
+		result.Identities[i] = UserIdentityDto{
+			Provider:    identity.Provider,
+			Subject:     identity.Subject,
+			Email:       identity.Email,
+			CreatedDate: identity.CreatedDate,
+		}
+	}
+	for i, location := range export.Locations {
+		result.Locations[i] = LocationDto{}.DomainToDto(location)
+	}
+	for i, group := range export.Groups {
+		result.Groups[i] = GroupDto{}.DomainToDto(group)
+	}
+	for i, member := range export.Memberships {
+		result.Memberships[i] = GroupMemberDto{}.DomainToDto(member)
+	}
+	for i, event := range export.OccupancyEvents {
+		result.OccupancyEvents[i] = OccupancyEventDto{}.DomainToDto(event)
+	}
+
+	return result
+}
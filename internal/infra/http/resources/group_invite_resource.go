@@ -0,0 +1,46 @@
+package resources
+
+import (
+	"boilerplate/internal/domain"
+	"time"
+)
+
+type GroupInviteDto struct {
+	Id          uint64     `json:"id"`
+	GroupId     uint64     `json:"group_id"`
+	UserId      uint64     `json:"user_id"`
+	Code        string     `json:"code"`
+	MaxUses     uint64     `json:"max_uses"`
+	Uses        uint64     `json:"uses"`
+	Active      bool       `json:"active"`
+	ExpiresDate *time.Time `json:"expires_date"`
+	RevokedDate *time.Time `json:"revoked_date"`
+	CreatedDate time.Time  `json:"created_date"`
+}
+
+type GroupInvitesDto struct {
+	Items []GroupInviteDto `json:"items"`
+}
+
+func (d GroupInviteDto) DomainToDto(invite domain.GroupInvite) GroupInviteDto {
+	return GroupInviteDto{
+		Id:          invite.Id,
+		GroupId:     invite.GroupId,
+		UserId:      invite.UserId,
+		Code:        invite.Code,
+		MaxUses:     invite.MaxUses,
+		Uses:        invite.Uses,
+		Active:      invite.IsActive(time.Now()),
+		ExpiresDate: invite.ExpiresDate,
+		RevokedDate: invite.RevokedDate,
+		CreatedDate: invite.CreatedDate,
+	}
+}
+
+func (d GroupInviteDto) DomainToDtoCollection(invites []domain.GroupInvite) GroupInvitesDto {
+	result := make([]GroupInviteDto, len(invites))
+	for i := range invites {
+		result[i] = d.DomainToDto(invites[i])
+	}
+	return GroupInvitesDto{Items: result}
+}
@@ -0,0 +1,50 @@
+package resources
+
+import (
+	"boilerplate/internal/domain"
+	"time"
+)
+
+type GroupJoinRequestDto struct {
+	Id          uint64     `json:"id"`
+	GroupId     uint64     `json:"group_id"`
+	UserId      uint64     `json:"user_id"`
+	Status      string     `json:"status"`
+	DecidedBy   *uint64    `json:"decided_by"`
+	DecidedDate *time.Time `json:"decided_date"`
+	CreatedDate time.Time  `json:"created_date"`
+}
+
+type GroupJoinRequestsDto struct {
+	Items []GroupJoinRequestDto `json:"items"`
+	Total uint64                `json:"total"`
+	Pages uint                  `json:"pages"`
+}
+
+func (d GroupJoinRequestDto) DomainToDto(joinRequest domain.GroupJoinRequest) GroupJoinRequestDto {
+	return GroupJoinRequestDto{
+		Id:          joinRequest.Id,
+		GroupId:     joinRequest.GroupId,
+		UserId:      joinRequest.UserId,
+		Status:      joinRequest.Status,
+		DecidedBy:   joinRequest.DecidedBy,
+		DecidedDate: joinRequest.DecidedDate,
+		CreatedDate: joinRequest.CreatedDate,
+	}
+}
+
+func (d GroupJoinRequestDto) DomainToDtoCollection(joinRequests []domain.GroupJoinRequest) GroupJoinRequestsDto {
+	result := make([]GroupJoinRequestDto, len(joinRequests))
+	for i := range joinRequests {
+		result[i] = d.DomainToDto(joinRequests[i])
+	}
+	return GroupJoinRequestsDto{Items: result, Total: uint64(len(result))}
+}
+
+func (d GroupJoinRequestDto) DomainToDtoPaginatedCollection(joinRequests domain.GroupJoinRequests, pag domain.Pagination) GroupJoinRequestsDto {
+	result := make([]GroupJoinRequestDto, len(joinRequests.Items))
+	for i := range joinRequests.Items {
+		result[i] = d.DomainToDto(joinRequests.Items[i])
+	}
+	return GroupJoinRequestsDto{Items: result, Pages: joinRequests.Pages, Total: joinRequests.Total}
+}
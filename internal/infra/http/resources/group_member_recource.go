@@ -5,10 +5,10 @@ import (
 )
 
 type GroupMemberDto struct {
-	Id          uint64 `json:"id,omitempty"`
-	UserId      uint64 `json:"user_id"`
-	GroupId     uint64 `json:"group_id"`
-	AccessLevel string `json:"access_level"`
+	Id      uint64 `json:"id,omitempty"`
+	UserId  uint64 `json:"user_id"`
+	GroupId uint64 `json:"group_id"`
+	RoleId  uint64 `json:"role_id"`
 }
 
 type GroupMembersDto struct {
@@ -19,10 +19,10 @@ type GroupMembersDto struct {
 
 func (d GroupMemberDto) DomainToDto(groupMember domain.GroupMember) GroupMemberDto {
 	return GroupMemberDto{
-		Id:          groupMember.Id,
-		UserId:      groupMember.UserId,
-		GroupId:     groupMember.GroupId,
-		AccessLevel: groupMember.AccessLevel,
+		Id:      groupMember.Id,
+		UserId:  groupMember.UserId,
+		GroupId: groupMember.GroupId,
+		RoleId:  groupMember.RoleId,
 	}
 }
 
@@ -5,10 +5,12 @@ import (
 )
 
 type GroupDto struct {
-	Id          uint64 `json:"id,omitempty"`
-	UserId      uint64 `json:"user_id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	Id               uint64 `json:"id,omitempty"`
+	UserId           uint64 `json:"user_id"`
+	Title            string `json:"title"`
+	Description      string `json:"description"`
+	RequiresApproval bool   `json:"requires_approval"`
+	Discoverable     bool   `json:"discoverable"`
 }
 
 type GroupsDto struct {
@@ -19,10 +21,12 @@ type GroupsDto struct {
 
 func (d GroupDto) DomainToDto(group domain.Group) GroupDto {
 	return GroupDto{
-		Id:          group.Id,
-		UserId:      group.UserId,
-		Title:       group.Title,
-		Description: group.Description,
+		Id:               group.Id,
+		UserId:           group.UserId,
+		Title:            group.Title,
+		Description:      group.Description,
+		RequiresApproval: group.RequiresApproval,
+		Discoverable:     group.Discoverable,
 	}
 }
 
@@ -45,3 +49,64 @@ func (d GroupDto) DomainToDtoPaginatedCollection(groups domain.Groups, pag domai
 
 	return GroupsDto{Items: result, Pages: groups.Pages, Total: groups.Total}
 }
+
+type UserGroupDto struct {
+	GroupDto
+	Role GroupRoleDto `json:"role"`
+}
+
+type UserGroupsDto struct {
+	Items []UserGroupDto `json:"items"`
+	Total uint64         `json:"total"`
+	Pages uint           `json:"pages"`
+}
+
+func (d UserGroupDto) DomainToDto(group domain.UserGroup) UserGroupDto {
+	return UserGroupDto{
+		GroupDto: GroupDto{}.DomainToDto(group.Group),
+		Role:     GroupRoleDto{}.DomainToDto(group.Role),
+	}
+}
+
+func (d UserGroupDto) DomainToDtoPaginatedCollection(groups domain.UserGroups, pag domain.Pagination) UserGroupsDto {
+	result := make([]UserGroupDto, len(groups.Items))
+
+	for i := range groups.Items {
+		result[i] = d.DomainToDto(groups.Items[i])
+	}
+
+	return UserGroupsDto{Items: result, Pages: groups.Pages, Total: groups.Total}
+}
+
+// GroupDirectoryDto is what anyone can see of a discoverable group, the owner is left out.
+type GroupDirectoryDto struct {
+	Id               uint64 `json:"id"`
+	Title            string `json:"title"`
+	Description      string `json:"description"`
+	RequiresApproval bool   `json:"requires_approval"`
+}
+
+type GroupDirectoriesDto struct {
+	Items []GroupDirectoryDto `json:"items"`
+	Total uint64              `json:"total"`
+	Pages uint                `json:"pages"`
+}
+
+func (d GroupDirectoryDto) DomainToDto(group domain.Group) GroupDirectoryDto {
+	return GroupDirectoryDto{
+		Id:               group.Id,
+		Title:            group.Title,
+		Description:      group.Description,
+		RequiresApproval: group.RequiresApproval,
+	}
+}
+
+func (d GroupDirectoryDto) DomainToDtoPaginatedCollection(groups domain.Groups, pag domain.Pagination) GroupDirectoriesDto {
+	result := make([]GroupDirectoryDto, len(groups.Items))
+
+	for i := range groups.Items {
+		result[i] = d.DomainToDto(groups.Items[i])
+	}
+
+	return GroupDirectoriesDto{Items: result, Pages: groups.Pages, Total: groups.Total}
+}
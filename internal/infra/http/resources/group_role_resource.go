@@ -0,0 +1,37 @@
+package resources
+
+import (
+	"boilerplate/internal/domain"
+)
+
+type GroupRoleDto struct {
+	Id          uint64              `json:"id,omitempty"`
+	Name        string              `json:"name"`
+	Permissions []domain.Permission `json:"permissions"`
+	IsDefault   bool                `json:"is_default"`
+}
+
+type GroupRolesDto struct {
+	Items []GroupRoleDto `json:"items"`
+}
+
+func (d GroupRoleDto) DomainToDto(role domain.GroupRole) GroupRoleDto {
+	permissions := role.Permissions
+	if permissions == nil {
+		permissions = []domain.Permission{}
+	}
+	return GroupRoleDto{
+		Id:          role.Id,
+		Name:        role.Name,
+		Permissions: permissions,
+		IsDefault:   role.IsDefault,
+	}
+}
+
+func (d GroupRoleDto) DomainToDtoCollection(roles []domain.GroupRole) GroupRolesDto {
+	result := make([]GroupRoleDto, len(roles))
+	for i := range roles {
+		result[i] = d.DomainToDto(roles[i])
+	}
+	return GroupRolesDto{Items: result}
+}
@@ -0,0 +1,42 @@
+package resources
+
+import (
+	"boilerplate/internal/domain"
+	"time"
+)
+
+type GroupTransferDto struct {
+	Id          uint64     `json:"id"`
+	GroupId     uint64     `json:"group_id"`
+	FromUserId  uint64     `json:"from_user_id"`
+	ToUserId    uint64     `json:"to_user_id"`
+	Status      string     `json:"status"`
+	DecidedDate *time.Time `json:"decided_date"`
+	CreatedDate time.Time  `json:"created_date"`
+}
+
+type GroupTransfersDto struct {
+	Items []GroupTransferDto `json:"items"`
+}
+
+func (d GroupTransferDto) DomainToDto(transfer domain.GroupTransfer) GroupTransferDto {
+	return GroupTransferDto{
+		Id:          transfer.Id,
+		GroupId:     transfer.GroupId,
+		FromUserId:  transfer.FromUserId,
+		ToUserId:    transfer.ToUserId,
+		Status:      transfer.Status,
+		DecidedDate: transfer.DecidedDate,
+		CreatedDate: transfer.CreatedDate,
+	}
+}
+
+func (d GroupTransferDto) DomainToDtoCollection(transfers []domain.GroupTransfer) GroupTransfersDto {
+	result := make([]GroupTransferDto, len(transfers))
+
+	for i := range transfers {
+		result[i] = d.DomainToDto(transfers[i])
+	}
+
+	return GroupTransfersDto{Items: result}
+}
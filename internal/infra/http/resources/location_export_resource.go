@@ -0,0 +1,137 @@
+package resources
+
+import (
+	"boilerplate/internal/domain"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// LocationStreamWriter writes locations one by one, so an export never holds
+// the whole result in memory.
+type LocationStreamWriter interface {
+	ContentType() string
+	FileName() string
+	Begin() error
+	Write(location domain.Location) error
+	End() error
+}
+
+func NewLocationStreamWriter(format string, w io.Writer) (LocationStreamWriter, error) {
+	switch format {
+	case domain.GeoJsonFormat:
+		return &geoJsonWriter{w: w}, nil
+	case domain.GpxFormat:
+		return &gpxWriter{w: w, enc: xml.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %s", format)
+	}
+}
+
+type geoJsonFeatureDto struct {
+	Type       string             `json:"type"`
+	Geometry   geoJsonGeometryDto `json:"geometry"`
+	Properties LocationDto        `json:"properties"`
+}
+
+type geoJsonGeometryDto struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+type geoJsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (g *geoJsonWriter) ContentType() string {
+	return "application/geo+json"
+}
+
+func (g *geoJsonWriter) FileName() string {
+	return "locations.geojson"
+}
+
+func (g *geoJsonWriter) Begin() error {
+	_, err := io.WriteString(g.w, `{"type":"FeatureCollection","features":[`)
+	return err
+}
+
+func (g *geoJsonWriter) Write(location domain.Location) error {
+	if g.count > 0 {
+		if _, err := io.WriteString(g.w, ","); err != nil {
+			return err
+		}
+	}
+	g.count++
+
+	feature := geoJsonFeatureDto{
+		Type: "Feature",
+		Geometry: geoJsonGeometryDto{
+			Type:        "Point",
+			Coordinates: [2]float64{location.Lon, location.Lat},
+		},
+		Properties: LocationDto{}.DomainToDto(location),
+	}
+	body, err := json.Marshal(feature)
+	if err != nil {
+		return err
+	}
+	_, err = g.w.Write(body)
+	return err
+}
+
+func (g *geoJsonWriter) End() error {
+	_, err := io.WriteString(g.w, "]}\n")
+	return err
+}
+
+type gpxWaypointDto struct {
+	XMLName     xml.Name `xml:"wpt"`
+	Lat         float64  `xml:"lat,attr"`
+	Lon         float64  `xml:"lon,attr"`
+	Name        string   `xml:"name"`
+	Description string   `xml:"desc,omitempty"`
+	Comment     string   `xml:"cmt,omitempty"`
+	Type        string   `xml:"type,omitempty"`
+}
+
+type gpxWriter struct {
+	w   io.Writer
+	enc *xml.Encoder
+}
+
+func (g *gpxWriter) ContentType() string {
+	return "application/gpx+xml"
+}
+
+func (g *gpxWriter) FileName() string {
+	return "locations.gpx"
+}
+
+func (g *gpxWriter) Begin() error {
+	_, err := io.WriteString(g.w, xml.Header+`<gpx version="1.1" creator="zahyst" xmlns="http://www.topografix.com/GPX/1/1">`)
+	return err
+}
+
+func (g *gpxWriter) Write(location domain.Location) error {
+	dto := LocationDto{}.DomainToDto(location)
+	err := g.enc.Encode(gpxWaypointDto{
+		Lat:         dto.Lat,
+		Lon:         dto.Lon,
+		Name:        dto.Title,
+		Description: dto.Description,
+		Comment:     dto.Address,
+		Type:        dto.Type,
+	})
+	if err != nil {
+		return err
+	}
+	return g.enc.Flush()
+}
+
+func (g *gpxWriter) End() error {
+	_, err := io.WriteString(g.w, "</gpx>\n")
+	return err
+}
@@ -0,0 +1,43 @@
+package resources
+
+import (
+	"boilerplate/internal/domain"
+)
+
+type LocationImportDto struct {
+	DryRun   bool                     `json:"dry_run"`
+	Total    int                      `json:"total"`
+	Valid    int                      `json:"valid"`
+	Imported int                      `json:"imported"`
+	Errors   []LocationImportErrorDto `json:"errors"`
+	Items    []LocationDto            `json:"items"`
+}
+
+type LocationImportErrorDto struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+func (d LocationImportDto) DomainToDto(locationImport domain.LocationImport) LocationImportDto {
+	result := LocationImportDto{
+		DryRun: locationImport.DryRun,
+		Total:  len(locationImport.Rows),
+		Errors: []LocationImportErrorDto{},
+		Items:  []LocationDto{},
+	}
+
+	var locationDto LocationDto
+	for _, row := range locationImport.Rows {
+		if row.Err != nil {
+			result.Errors = append(result.Errors, LocationImportErrorDto{Row: row.Row, Error: row.Err.Error()})
+			continue
+		}
+		result.Valid++
+		if !locationImport.DryRun {
+			result.Imported++
+			result.Items = append(result.Items, locationDto.DomainToDto(row.Location))
+		}
+	}
+
+	return result
+}
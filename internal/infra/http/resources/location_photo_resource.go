@@ -0,0 +1,35 @@
+package resources
+
+import (
+	"boilerplate/internal/domain"
+	"fmt"
+)
+
+type LocationPhotoDto struct {
+	Id          uint64 `json:"id"`
+	Url         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Size        uint64 `json:"size"`
+}
+
+func (d LocationPhotoDto) DomainToDto(photo domain.LocationPhoto) LocationPhotoDto {
+	return LocationPhotoDto{
+		Id:          photo.Id,
+		Url:         fmt.Sprintf("/api/v1/locations/%d/photos/%d", photo.LocationId, photo.Id),
+		ContentType: photo.ContentType,
+		Size:        photo.Size,
+	}
+}
+
+func (d LocationPhotoDto) DomainToDtoCollection(photos []domain.LocationPhoto) []LocationPhotoDto {
+	if len(photos) == 0 {
+		return nil
+	}
+
+	result := make([]LocationPhotoDto, len(photos))
+	for i := range photos {
+		result[i] = d.DomainToDto(photos[i])
+	}
+
+	return result
+}
@@ -5,14 +5,33 @@ import (
 )
 
 type LocationDto struct {
-	Id          uint64  `json:"id,omitempty"`
-	UserId      uint64  `json:"user_id"`
-	Type        string  `json:"type"`
-	Address     string  `json:"address"`
-	Title       string  `json:"title"`
-	Description string  `json:"description"`
-	Lat         float64 `json:"lat"`
-	Lon         float64 `json:"lon"`
+	Id           uint64             `json:"id,omitempty"`
+	UserId       uint64             `json:"user_id"`
+	Type         string             `json:"type"`
+	Address      string             `json:"address"`
+	Title        string             `json:"title"`
+	Description  string             `json:"description"`
+	Lat          float64            `json:"lat"`
+	Lon          float64            `json:"lon"`
+	Capacity     uint64             `json:"capacity"`
+	Occupancy    uint64             `json:"occupancy"`
+	OpeningHours OpeningHoursDto    `json:"opening_hours"`
+	Attributes   []string           `json:"attributes"`
+	Photos       []LocationPhotoDto `json:"photos,omitempty"`
+	Visibility   string             `json:"visibility"`
+	GroupIds     []uint64           `json:"group_ids,omitempty"`
+	Distance     *float64           `json:"distance,omitempty"`
+}
+
+type OpeningHoursDto struct {
+	AlwaysOpen bool               `json:"always_open"`
+	Periods    []OpeningPeriodDto `json:"periods"`
+}
+
+type OpeningPeriodDto struct {
+	Weekday int    `json:"weekday"`
+	Opens   string `json:"opens"`
+	Closes  string `json:"closes"`
 }
 
 type LocationsDto struct {
@@ -23,14 +42,37 @@ type LocationsDto struct {
 
 func (d LocationDto) DomainToDto(location domain.Location) LocationDto {
 	return LocationDto{
-		Id:          location.Id,
-		UserId:      location.UserId,
-		Type:        location.Type,
-		Address:     location.Address,
-		Title:       location.Title,
-		Description: location.Description,
-		Lat:         location.Lat,
-		Lon:         location.Lon,
+		Id:           location.Id,
+		UserId:       location.UserId,
+		Type:         location.Type,
+		Address:      location.Address,
+		Title:        location.Title,
+		Description:  location.Description,
+		Lat:          location.Lat,
+		Lon:          location.Lon,
+		Capacity:     location.Capacity,
+		Occupancy:    location.Occupancy,
+		OpeningHours: OpeningHoursDto{}.DomainToDto(location.OpeningHours),
+		Attributes:   location.Attributes,
+		Photos:       LocationPhotoDto{}.DomainToDtoCollection(location.Photos),
+		Visibility:   location.Visibility,
+		GroupIds:     location.GroupIds,
+		Distance:     location.Distance,
+	}
+}
+
+func (d OpeningHoursDto) DomainToDto(openingHours domain.OpeningHours) OpeningHoursDto {
+	periods := make([]OpeningPeriodDto, len(openingHours.Periods))
+	for i, p := range openingHours.Periods {
+		periods[i] = OpeningPeriodDto{
+			Weekday: int(p.Weekday),
+			Opens:   p.Opens,
+			Closes:  p.Closes,
+		}
+	}
+	return OpeningHoursDto{
+		AlwaysOpen: openingHours.AlwaysOpen,
+		Periods:    periods,
 	}
 }
 
@@ -0,0 +1,54 @@
+package resources
+
+import (
+	"boilerplate/internal/domain"
+	"time"
+)
+
+type LocationRevisionDto struct {
+	Id          uint64                          `json:"id"`
+	LocationId  uint64                          `json:"location_id"`
+	UserId      uint64                          `json:"user_id"`
+	Action      string                          `json:"action"`
+	Snapshot    LocationDto                     `json:"snapshot"`
+	Diff        map[string]LocationFieldDiffDto `json:"diff"`
+	CreatedDate time.Time                       `json:"created_date"`
+}
+
+type LocationFieldDiffDto struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+type LocationRevisionsDto struct {
+	Items []LocationRevisionDto `json:"items"`
+	Total uint64                `json:"total"`
+	Pages uint                  `json:"pages"`
+}
+
+func (d LocationRevisionDto) DomainToDto(revision domain.LocationRevision) LocationRevisionDto {
+	diff := make(map[string]LocationFieldDiffDto, len(revision.Diff))
+	for name, change := range revision.Diff {
+		diff[name] = LocationFieldDiffDto{Old: change.Old, New: change.New}
+	}
+
+	return LocationRevisionDto{
+		Id:          revision.Id,
+		LocationId:  revision.LocationId,
+		UserId:      revision.UserId,
+		Action:      revision.Action,
+		Snapshot:    LocationDto{}.DomainToDto(revision.Snapshot),
+		Diff:        diff,
+		CreatedDate: revision.CreatedDate,
+	}
+}
+
+func (d LocationRevisionDto) DomainToDtoPaginatedCollection(revisions domain.LocationRevisions, pag domain.Pagination) LocationRevisionsDto {
+	result := make([]LocationRevisionDto, len(revisions.Items))
+
+	for i := range revisions.Items {
+		result[i] = d.DomainToDto(revisions.Items[i])
+	}
+
+	return LocationRevisionsDto{Items: result, Pages: revisions.Pages, Total: revisions.Total}
+}
@@ -0,0 +1,35 @@
+package resources
+
+import (
+	"boilerplate/internal/domain"
+)
+
+type LocationTypeDto struct {
+	Id    uint64 `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Title string `json:"title"`
+}
+
+type LocationTypesDto struct {
+	Items []LocationTypeDto `json:"items"`
+	Total uint64            `json:"total"`
+	Pages uint              `json:"pages"`
+}
+
+func (d LocationTypeDto) DomainToDto(locationType domain.LocationType) LocationTypeDto {
+	return LocationTypeDto{
+		Id:    locationType.Id,
+		Name:  locationType.Name,
+		Title: locationType.Title,
+	}
+}
+
+func (d LocationTypeDto) DomainToDtoPaginatedCollection(locationTypes domain.LocationTypes, pag domain.Pagination) LocationTypesDto {
+	result := make([]LocationTypeDto, len(locationTypes.Items))
+
+	for i := range locationTypes.Items {
+		result[i] = d.DomainToDto(locationTypes.Items[i])
+	}
+
+	return LocationTypesDto{Items: result, Pages: locationTypes.Pages, Total: locationTypes.Total}
+}
@@ -0,0 +1,42 @@
+package resources
+
+import (
+	"boilerplate/internal/domain"
+	"time"
+)
+
+type OccupancyEventDto struct {
+	Id          uint64    `json:"id"`
+	LocationId  uint64    `json:"location_id"`
+	UserId      uint64    `json:"user_id"`
+	Action      string    `json:"action"`
+	Occupancy   uint64    `json:"occupancy"`
+	CreatedDate time.Time `json:"created_date"`
+}
+
+type OccupancyEventsDto struct {
+	Items []OccupancyEventDto `json:"items"`
+	Total uint64              `json:"total"`
+	Pages uint                `json:"pages"`
+}
+
+func (d OccupancyEventDto) DomainToDto(event domain.OccupancyEvent) OccupancyEventDto {
+	return OccupancyEventDto{
+		Id:          event.Id,
+		LocationId:  event.LocationId,
+		UserId:      event.UserId,
+		Action:      event.Action,
+		Occupancy:   event.Occupancy,
+		CreatedDate: event.CreatedDate,
+	}
+}
+
+func (d OccupancyEventDto) DomainToDtoPaginatedCollection(events domain.OccupancyEvents, pag domain.Pagination) OccupancyEventsDto {
+	result := make([]OccupancyEventDto, len(events.Items))
+
+	for i := range events.Items {
+		result[i] = d.DomainToDto(events.Items[i])
+	}
+
+	return OccupancyEventsDto{Items: result, Pages: events.Pages, Total: events.Total}
+}
@@ -0,0 +1,9 @@
+package resources
+
+type OidcProvidersDto struct {
+	Providers []string `json:"providers"`
+}
+
+type OidcAuthorizationDto struct {
+	Url string `json:"url"`
+}
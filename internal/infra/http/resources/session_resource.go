@@ -0,0 +1,38 @@
+package resources
+
+import (
+	"boilerplate/internal/domain"
+	"time"
+)
+
+type SessionDto struct {
+	Id           string    `json:"id"`
+	UserAgent    string    `json:"user_agent"`
+	Ip           string    `json:"ip"`
+	Current      bool      `json:"current"`
+	CreatedDate  time.Time `json:"created_date"`
+	LastSeenDate time.Time `json:"last_seen_date"`
+}
+
+type SessionsDto struct {
+	Items []SessionDto `json:"items"`
+}
+
+func (d SessionDto) DomainToDto(sess domain.Session, current domain.Session) SessionDto {
+	return SessionDto{
+		Id:           sess.UUID.String(),
+		UserAgent:    sess.UserAgent,
+		Ip:           sess.Ip,
+		Current:      sess.UUID == current.UUID,
+		CreatedDate:  sess.CreatedDate,
+		LastSeenDate: sess.LastSeenDate,
+	}
+}
+
+func (d SessionDto) DomainToDtoCollection(sessions []domain.Session, current domain.Session) SessionsDto {
+	result := make([]SessionDto, len(sessions))
+	for i := range sessions {
+		result[i] = d.DomainToDto(sessions[i], current)
+	}
+	return SessionsDto{Items: result}
+}
@@ -0,0 +1,28 @@
+package resources
+
+import "boilerplate/internal/domain"
+
+type TwoFactorStatusDto struct {
+	Enabled bool `json:"enabled"`
+}
+
+type TwoFactorEnrollmentDto struct {
+	Secret string `json:"secret"`
+	Uri    string `json:"uri"`
+}
+
+type RecoveryCodesDto struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+type TwoFactorChallengeDto struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+}
+
+func (d TwoFactorEnrollmentDto) DomainToDto(enrollment domain.TwoFactorEnrollment) TwoFactorEnrollmentDto {
+	return TwoFactorEnrollmentDto{
+		Secret: enrollment.Secret,
+		Uri:    enrollment.Uri,
+	}
+}
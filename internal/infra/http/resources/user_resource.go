@@ -1,11 +1,16 @@
 package resources
 
-import "boilerplate/internal/domain"
+import (
+	"boilerplate/internal/domain"
+	"time"
+)
 
 type UserDto struct {
-	Id    uint64 `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	Id       uint64 `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	Verified bool   `json:"verified"`
 }
 
 type UsersDto struct {
@@ -15,8 +20,10 @@ type UsersDto struct {
 }
 
 type AuthDto struct {
-	Token string  `json:"token"`
-	User  UserDto `json:"user"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	User         UserDto   `json:"user"`
 }
 
 type UserCoordinatesDto struct {
@@ -26,9 +33,11 @@ type UserCoordinatesDto struct {
 
 func (d UserDto) DomainToDto(user domain.User) UserDto {
 	return UserDto{
-		Id:    user.Id,
-		Name:  user.Name,
-		Email: user.Email,
+		Id:       user.Id,
+		Name:     user.Name,
+		Email:    user.Email,
+		Role:     user.Role,
+		Verified: user.IsVerified(),
 	}
 }
 
@@ -42,10 +51,12 @@ func (d UserDto) DomainToDtoCollection(users domain.Users) UsersDto {
 	return UsersDto{Items: result, Pages: users.Pages, Total: users.Total}
 }
 
-func (d AuthDto) DomainToDto(token string, user domain.User) AuthDto {
+func (d AuthDto) DomainToDto(tokens domain.AuthTokens, user domain.User) AuthDto {
 	var userDto UserDto
 	return AuthDto{
-		Token: token,
-		User:  userDto.DomainToDto(user),
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    tokens.ExpiresAt,
+		User:         userDto.DomainToDto(user),
 	}
 }
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -23,11 +24,11 @@ func Router(cont container.Container) http.Handler {
 
 	router := chi.NewRouter()
 
-	router.Use(middleware.RedirectSlashes, middleware.Logger, cors.Handler(cors.Options{
+	router.Use(cont.RealIpMw, middleware.RedirectSlashes, middleware.Logger, cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "Content-Disposition", "Retry-After"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
@@ -43,7 +44,8 @@ func Router(cont container.Container) http.Handler {
 			// Public routes
 			apiRouter.Group(func(apiRouter chi.Router) {
 				apiRouter.Route("/auth", func(apiRouter chi.Router) {
-					AuthRouter(apiRouter, cont.AuthController, cont.AuthMw)
+					AuthRouter(apiRouter, cont.AuthController, cont.TwoFactorController, cont.AuthMw)
+					OidcRouter(apiRouter, cont.OidcController)
 				})
 			})
 
@@ -51,17 +53,26 @@ func Router(cont container.Container) http.Handler {
 			apiRouter.Group(func(apiRouter chi.Router) {
 				apiRouter.Use(cont.AuthMw)
 
-				LocationRouter(apiRouter, cont.LocationController, cont.LocationService)
+				LocationRouter(apiRouter, cont.LocationController, cont.LocationService, cont.LocationPhotoService)
+				LocationTypeRouter(apiRouter, cont.LocationTypeController, cont.LocationTypeService)
 				UserRouter(apiRouter, cont.UserController)
-				GroupRouter(apiRouter, cont.GroupController, cont.GroupService)
-				GroupMemberRouter(apiRouter, cont.GroupMemberController, cont.GroupMemberService, cont.GroupService)
+				GroupRouter(apiRouter, cont.GroupController, cont.GroupInviteController, cont.GroupTransferController, cont.GroupRoleController, cont.GroupService, cont.GroupInviteService, cont.GroupMemberService, cont.GroupTransferService, cont.GroupRoleService, cont.VerifiedMw)
+				GroupMemberRouter(apiRouter, cont.GroupMemberController, cont.GroupJoinRequestController, cont.GroupMemberService, cont.GroupService, cont.GroupJoinRequestService, cont.VerifiedMw)
 
 				apiRouter.Handle("/*", NotFoundJSON())
 			})
 		})
 	})
 
+	router.Get("/.well-known/jwks.json", cont.AuthController.Jwks())
+
 	router.Get("/static/*", func(w http.ResponseWriter, r *http.Request) {
+		// location photos are served by the locations API, which checks the visibility
+		file := path.Clean(strings.TrimPrefix(r.URL.Path, "/static"))
+		if file == "/locations" || strings.HasPrefix(file, "/locations/") {
+			NotFoundJSON()(w, r)
+			return
+		}
 		workDir, _ := os.Getwd()
 		filesDir := http.Dir(filepath.Join(workDir, config.GetConfiguration().FileStorageLocation))
 		rctx := chi.RouteContext(r.Context())
@@ -73,7 +84,7 @@ func Router(cont container.Container) http.Handler {
 	return router
 }
 
-func AuthRouter(r chi.Router, ac controllers.AuthController, amw func(http.Handler) http.Handler) {
+func AuthRouter(r chi.Router, ac controllers.AuthController, tfc controllers.TwoFactorController, amw func(http.Handler) http.Handler) {
 	r.Route("/", func(apiRouter chi.Router) {
 		apiRouter.Post(
 			"/register",
@@ -83,6 +94,34 @@ func AuthRouter(r chi.Router, ac controllers.AuthController, amw func(http.Handl
 			"/login",
 			ac.Login(),
 		)
+		apiRouter.Post(
+			"/restore",
+			ac.Restore(),
+		)
+		apiRouter.Post(
+			"/login/2fa",
+			ac.LoginTwoFactor(),
+		)
+		apiRouter.Post(
+			"/refresh",
+			ac.Refresh(),
+		)
+		apiRouter.Post(
+			"/forgot-password",
+			ac.ForgotPassword(),
+		)
+		apiRouter.Post(
+			"/reset-password",
+			ac.ResetPassword(),
+		)
+		apiRouter.Get(
+			"/verify-email",
+			ac.VerifyEmail(),
+		)
+		apiRouter.With(amw).Post(
+			"/verify-email/resend",
+			ac.ResendVerification(),
+		)
 		apiRouter.With(amw).Post(
 			"/change-pwd",
 			ac.ChangePassword(),
@@ -91,6 +130,51 @@ func AuthRouter(r chi.Router, ac controllers.AuthController, amw func(http.Handl
 			"/logout",
 			ac.Logout(),
 		)
+		apiRouter.With(amw).Get(
+			"/2fa",
+			tfc.Status(),
+		)
+		apiRouter.With(amw).Post(
+			"/2fa/enroll",
+			tfc.Enroll(),
+		)
+		apiRouter.With(amw).Post(
+			"/2fa/confirm",
+			tfc.Confirm(),
+		)
+		apiRouter.With(amw).Post(
+			"/2fa/disable",
+			tfc.Disable(),
+		)
+		apiRouter.With(amw).Get(
+			"/sessions",
+			ac.Sessions(),
+		)
+		apiRouter.With(amw).Delete(
+			"/sessions",
+			ac.RevokeOtherSessions(),
+		)
+		apiRouter.With(amw).Delete(
+			"/sessions/{sessionId}",
+			ac.RevokeSession(),
+		)
+	})
+}
+
+func OidcRouter(r chi.Router, oc controllers.OidcController) {
+	r.Route("/oidc", func(apiRouter chi.Router) {
+		apiRouter.Get(
+			"/",
+			oc.Providers(),
+		)
+		apiRouter.Get(
+			"/{provider}",
+			oc.Authorize(),
+		)
+		apiRouter.Get(
+			"/{provider}/callback",
+			oc.Callback(),
+		)
 	})
 }
 
@@ -108,6 +192,10 @@ func UserRouter(r chi.Router, uc controllers.UserController) {
 			"/",
 			uc.Delete(),
 		)
+		apiRouter.Get(
+			"/export",
+			uc.Export(),
+		)
 		apiRouter.Put(
 			"/coordinates",
 			uc.SetCoordinates(),
@@ -119,10 +207,12 @@ func UserRouter(r chi.Router, uc controllers.UserController) {
 	})
 }
 
-func LocationRouter(r chi.Router, lc controllers.LocationController, ls app.LocationService) {
+func LocationRouter(r chi.Router, lc controllers.LocationController, ls app.LocationService, lps app.LocationPhotoService) {
 	r.Route("/locations", func(apiRouter chi.Router) {
 		lpom := middlewares.PathObject("locationId", controllers.LocationKey, ls)
+		ppom := middlewares.PathObject("photoId", controllers.LocationPhotoKey, lps)
 		omw := middlewares.IsOwnerMiddleware[domain.Location](controllers.LocationKey)
+		vmw := middlewares.IsVisibleMiddleware(ls, controllers.LocationKey)
 		apiRouter.Post(
 			"/",
 			lc.Save(),
@@ -135,10 +225,54 @@ func LocationRouter(r chi.Router, lc controllers.LocationController, ls app.Loca
 			"/in-area",
 			lc.FindByArea(),
 		)
-		apiRouter.With(lpom).Get(
+		apiRouter.Post(
+			"/nearest",
+			lc.FindNearest(),
+		)
+		apiRouter.Post(
+			"/search",
+			lc.Search(),
+		)
+		apiRouter.Post(
+			"/import",
+			lc.Import(),
+		)
+		apiRouter.With(lpom, vmw).Get(
 			"/{locationId}",
 			lc.Detail(),
 		)
+		apiRouter.With(lpom, vmw).Post(
+			"/{locationId}/check-in",
+			lc.CheckIn(),
+		)
+		apiRouter.With(lpom, vmw).Post(
+			"/{locationId}/check-out",
+			lc.CheckOut(),
+		)
+		apiRouter.With(lpom, omw).Get(
+			"/{locationId}/occupancy",
+			lc.OccupancyHistory(),
+		)
+		apiRouter.With(lpom, omw).Post(
+			"/{locationId}/photos",
+			lc.UploadPhoto(),
+		)
+		apiRouter.With(lpom, vmw, ppom).Get(
+			"/{locationId}/photos/{photoId}",
+			lc.Photo(),
+		)
+		apiRouter.With(lpom, omw, ppom).Delete(
+			"/{locationId}/photos/{photoId}",
+			lc.DeletePhoto(),
+		)
+		apiRouter.With(lpom, omw).Get(
+			"/{locationId}/history",
+			lc.History(),
+		)
+		apiRouter.With(lpom, omw).Post(
+			"/{locationId}/history/{revisionId}/revert",
+			lc.Revert(),
+		)
 		apiRouter.With(lpom, omw).Put(
 			"/{locationId}",
 			lc.Update(),
@@ -150,11 +284,43 @@ func LocationRouter(r chi.Router, lc controllers.LocationController, ls app.Loca
 	})
 }
 
-func GroupRouter(r chi.Router, gc controllers.GroupController, gs app.GroupService) {
+func LocationTypeRouter(r chi.Router, ltc controllers.LocationTypeController, lts app.LocationTypeService) {
+	r.Route("/location-types", func(apiRouter chi.Router) {
+		ltpom := middlewares.PathObject("locationTypeId", controllers.LocationTypeKey, lts)
+		apiRouter.Get(
+			"/",
+			ltc.GetList(),
+		)
+		apiRouter.With(middlewares.IsAdminMiddleware).Post(
+			"/",
+			ltc.Save(),
+		)
+		apiRouter.With(ltpom).Get(
+			"/{locationTypeId}",
+			ltc.Detail(),
+		)
+		apiRouter.With(ltpom, middlewares.IsAdminMiddleware).Put(
+			"/{locationTypeId}",
+			ltc.Update(),
+		)
+		apiRouter.With(ltpom, middlewares.IsAdminMiddleware).Delete(
+			"/{locationTypeId}",
+			ltc.Delete(),
+		)
+	})
+}
+
+func GroupRouter(r chi.Router, gc controllers.GroupController, gic controllers.GroupInviteController, gtc controllers.GroupTransferController, grc controllers.GroupRoleController, gs app.GroupService, gis app.GroupInviteService, gms app.GroupMemberService, gts app.GroupTransferService, grs app.GroupRoleService, vmw func(http.Handler) http.Handler) {
 	r.Route("/groups", func(apiRouter chi.Router) {
 		gpom := middlewares.PathObject("groupId", controllers.GroupKey, gs)
+		ipom := middlewares.PathObject("inviteId", controllers.GroupInviteKey, gis)
+		tpom := middlewares.PathObject("transferId", controllers.GroupTransferKey, gts)
+		rpom := middlewares.PathObject("roleId", controllers.GroupRoleKey, grs)
 		omw := middlewares.IsOwnerMiddleware[domain.Group](controllers.GroupKey)
-		apiRouter.Post(
+		tomw := middlewares.IsOwnerMiddleware[domain.GroupTransfer](controllers.GroupTransferKey)
+		canedit := middlewares.CheckRoleMiddleware(domain.GroupEditPermission, gs, gms, "groupId")
+		canmanage := middlewares.CheckRoleMiddleware(domain.MembersManagePermission, gs, gms, "groupId")
+		apiRouter.With(vmw).Post(
 			"/",
 			gc.Save(),
 		)
@@ -162,15 +328,27 @@ func GroupRouter(r chi.Router, gc controllers.GroupController, gs app.GroupServi
 			"/list",
 			gc.GetList(),
 		)
-		apiRouter.With(gpom, omw).Get(
-			"/access_code/{groupId}",
-			gc.GetAccessCode(),
+		apiRouter.Get(
+			"/directory",
+			gc.Directory(),
+		)
+		apiRouter.Get(
+			"/transfers",
+			gtc.FindMy(),
+		)
+		apiRouter.With(tpom, tomw).Post(
+			"/transfers/{transferId}/accept",
+			gtc.Accept(),
+		)
+		apiRouter.With(tpom, tomw).Post(
+			"/transfers/{transferId}/decline",
+			gtc.Decline(),
 		)
 		apiRouter.With(gpom).Get(
 			"/{groupId}",
 			gc.Detail(),
 		)
-		apiRouter.With(gpom, omw).Put(
+		apiRouter.With(gpom, canedit).Put(
 			"/{groupId}",
 			gc.Update(),
 		)
@@ -178,31 +356,100 @@ func GroupRouter(r chi.Router, gc controllers.GroupController, gs app.GroupServi
 			"/{groupId}",
 			gc.Delete(),
 		)
+		apiRouter.With(gpom, omw).Get(
+			"/{groupId}/transfer",
+			gtc.Detail(),
+		)
+		apiRouter.With(gpom, omw).Post(
+			"/{groupId}/transfer",
+			gtc.Save(),
+		)
+		apiRouter.With(gpom, omw).Delete(
+			"/{groupId}/transfer",
+			gtc.Cancel(),
+		)
+		apiRouter.With(gpom, canmanage).Get(
+			"/{groupId}/invites",
+			gic.GetList(),
+		)
+		apiRouter.With(gpom, canmanage).Post(
+			"/{groupId}/invites",
+			gic.Save(),
+		)
+		apiRouter.With(gpom, canmanage, ipom).Delete(
+			"/{groupId}/invites/{inviteId}",
+			gic.Revoke(),
+		)
+		apiRouter.With(gpom, canmanage).Get(
+			"/{groupId}/roles",
+			grc.GetList(),
+		)
+		apiRouter.With(gpom, omw).Post(
+			"/{groupId}/roles",
+			grc.Save(),
+		)
+		apiRouter.With(gpom, omw, rpom).Put(
+			"/{groupId}/roles/{roleId}",
+			grc.Update(),
+		)
+		apiRouter.With(gpom, omw, rpom).Delete(
+			"/{groupId}/roles/{roleId}",
+			grc.Delete(),
+		)
 	})
 }
 
-func GroupMemberRouter(r chi.Router, gmc controllers.GroupMemberController, gms app.GroupMemberService, gs app.GroupService) {
+func GroupMemberRouter(r chi.Router, gmc controllers.GroupMemberController, gjrc controllers.GroupJoinRequestController, gms app.GroupMemberService, gs app.GroupService, gjrs app.GroupJoinRequestService, vmw func(http.Handler) http.Handler) {
 	r.Route("/members", func(apiRouter chi.Router) {
 		gmpom := middlewares.PathObject("groupMemberId", controllers.GroupMemberKey, gms)
-		ismoderator := middlewares.CheckRoleMiddleware([]domain.AccessLevel{domain.ModeratorAccessLevel{}, domain.AdminAccessLevel{}}, gs, gms, "groupId")
-		isadmin := middlewares.CheckRoleMiddleware([]domain.AccessLevel{domain.AdminAccessLevel{}}, gs, gms, "groupId")
-		apiRouter.Post(
+		gpom := middlewares.PathObject("groupId", controllers.GroupKey, gs)
+		jrpom := middlewares.PathObject("joinRequestId", controllers.JoinRequestKey, gjrs)
+		jromw := middlewares.IsOwnerMiddleware[domain.GroupJoinRequest](controllers.JoinRequestKey)
+		canview := middlewares.CheckRoleMiddleware(domain.MembersViewPermission, gs, gms, "groupId")
+		canlocate := middlewares.CheckRoleMiddleware(domain.MembersLocatePermission, gs, gms, "groupId")
+		canapprove := middlewares.CheckRoleMiddleware(domain.MembersApprovePermission, gs, gms, "groupId")
+		canmanage := middlewares.CheckRoleMiddleware(domain.MembersManagePermission, gs, gms, "groupId")
+		apiRouter.With(vmw).Post(
 			"/",
 			gmc.AddGroupMember(),
 		)
-		apiRouter.With(gmpom, isadmin).Put(
+		apiRouter.Get(
+			"/requests",
+			gjrc.FindMy(),
+		)
+		apiRouter.With(jrpom, jromw).Delete(
+			"/requests/{joinRequestId}",
+			gjrc.Cancel(),
+		)
+		apiRouter.With(gpom, canapprove).Get(
+			"/{groupId}/requests",
+			gjrc.GetList(),
+		)
+		apiRouter.With(gpom, canapprove, jrpom).Post(
+			"/{groupId}/requests/{joinRequestId}/approve",
+			gjrc.Approve(),
+		)
+		apiRouter.With(gpom, canapprove, jrpom).Post(
+			"/{groupId}/requests/{joinRequestId}/reject",
+			gjrc.Reject(),
+		)
+		apiRouter.With(gpom).Post(
+			"/{groupId}/leave",
+			gmc.Leave(),
+		)
+		apiRouter.With(gmpom, canmanage).Put(
 			"/{groupId}/{groupMemberId}",
-			gmc.ChangeAccessLevel(),
+			gmc.ChangeRole(),
 		)
-		apiRouter.With(gmpom, isadmin).Delete(
+		apiRouter.With(gmpom, canmanage).Delete(
 			"/{groupId}/{groupMemberId}",
 			gmc.DeleteGroupMember(),
 		)
-		apiRouter.With(ismoderator).Get(
+		apiRouter.With(canview).Get(
 			"/{groupId}",
 			gmc.GetMembersList(),
 		)
-		apiRouter.With(ismoderator).Post(
+		apiRouter.With(canlocate).Post(
 			"/{groupId}",
 			gmc.FindMembersByArea(),
 		)
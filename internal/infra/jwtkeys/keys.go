@@ -0,0 +1,199 @@
+// Package jwtkeys signs and verifies access tokens. Tokens are signed with a
+// single active key and carry its id in the kid header, any key of the
+// verification set is accepted, so a rotated key keeps working until the
+// tokens it signed expire.
+package jwtkeys
+
+import (
+	"crypto"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// hmacKeyId is used for the shared secret, a thumbprint of it would leak a hash of the secret.
+const hmacKeyId = "hs256"
+
+type Keys interface {
+	Sign(claims map[string]interface{}) (string, error)
+	Verify(tokenString string) (jwt.Token, error)
+	// PublicSet returns the keys other services may use to verify our tokens,
+	// it is empty for a shared secret.
+	PublicSet() jwk.Set
+}
+
+type keys struct {
+	alg       jwa.SignatureAlgorithm
+	signKey   jwk.Key
+	verifySet jwk.Set
+	publicSet jwk.Set
+}
+
+// NewHmacKeys signs with HS256 using the shared secret.
+func NewHmacKeys(secret []byte) (Keys, error) {
+	key, err := jwk.FromRaw(secret)
+	if err != nil {
+		return nil, err
+	}
+	err = setKeyMeta(key, jwa.HS256, hmacKeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	verifySet := jwk.NewSet()
+	err = verifySet.AddKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys{
+		alg:       jwa.HS256,
+		signKey:   key,
+		verifySet: verifySet,
+		publicSet: jwk.NewSet(),
+	}, nil
+}
+
+// NewAsymmetricKeys signs with the PEM encoded private key, RS256 for RSA and EdDSA for Ed25519 keys.
+// Extra PEM encoded public keys are accepted for verification only.
+func NewAsymmetricKeys(privatePem []byte, extraPublicPem []byte) (Keys, error) {
+	signKey, err := jwk.ParseKey(privatePem, jwk.WithPEM(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	alg, err := algorithmFor(signKey)
+	if err != nil {
+		return nil, err
+	}
+	kid, err := thumbprint(signKey)
+	if err != nil {
+		return nil, err
+	}
+	err = setKeyMeta(signKey, alg, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := signKey.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	publicSet := jwk.NewSet()
+	err = publicSet.AddKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(extraPublicPem) > 0 {
+		extra, err := jwk.Parse(extraPublicPem, jwk.WithPEM(true))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse verification keys: %w", err)
+		}
+		for i := 0; i < extra.Len(); i++ {
+			key, _ := extra.Key(i)
+			if !isPublic(key) {
+				return nil, errors.New("verification keys must be public keys")
+			}
+			keyAlg, err := algorithmFor(key)
+			if err != nil {
+				return nil, err
+			}
+			keyId, err := thumbprint(key)
+			if err != nil {
+				return nil, err
+			}
+			if keyId == kid {
+				continue
+			}
+			err = setKeyMeta(key, keyAlg, keyId)
+			if err != nil {
+				return nil, err
+			}
+			err = publicSet.AddKey(key)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return keys{
+		alg:       alg,
+		signKey:   signKey,
+		verifySet: publicSet,
+		publicSet: publicSet,
+	}, nil
+}
+
+func (k keys) Sign(claims map[string]interface{}) (string, error) {
+	t := jwt.New()
+	for name, value := range claims {
+		err := t.Set(name, value)
+		if err != nil {
+			return "", err
+		}
+	}
+	signed, err := jwt.Sign(t, jwt.WithKey(k.alg, k.signKey))
+	if err != nil {
+		return "", err
+	}
+	return string(signed), nil
+}
+
+func (k keys) Verify(tokenString string) (jwt.Token, error) {
+	return jwt.Parse([]byte(tokenString), jwt.WithKeySet(k.verifySet), jwt.WithValidate(true))
+}
+
+func (k keys) PublicSet() jwk.Set {
+	return k.publicSet
+}
+
+func algorithmFor(key jwk.Key) (jwa.SignatureAlgorithm, error) {
+	switch key.KeyType() {
+	case jwa.RSA:
+		return jwa.RS256, nil
+	case jwa.OKP:
+		return jwa.EdDSA, nil
+	default:
+		return "", fmt.Errorf("unsupported key type %s, use an RSA or Ed25519 key", key.KeyType())
+	}
+}
+
+func isPublic(key jwk.Key) bool {
+	switch key.(type) {
+	case jwk.RSAPublicKey, jwk.OKPPublicKey:
+		return true
+	default:
+		return false
+	}
+}
+
+// thumbprint builds the key id from the RFC 7638 thumbprint of the public key,
+// so the same key always gets the same id without extra configuration.
+func thumbprint(key jwk.Key) (string, error) {
+	public, err := key.PublicKey()
+	if err != nil {
+		return "", err
+	}
+	sum, err := public.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(sum), nil
+}
+
+func setKeyMeta(key jwk.Key, alg jwa.SignatureAlgorithm, kid string) error {
+	err := key.Set(jwk.AlgorithmKey, alg)
+	if err != nil {
+		return err
+	}
+	err = key.Set(jwk.KeyIDKey, kid)
+	if err != nil {
+		return err
+	}
+	return key.Set(jwk.KeyUsageKey, jwk.ForSignature)
+}
@@ -0,0 +1,275 @@
+// Package oidc is a minimal OpenID Connect relying party: discovery,
+// the authorization code flow with PKCE and ID token verification.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+type ProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+}
+
+// Claims are the identity details taken from a verified ID token.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type Provider interface {
+	Name() string
+	AuthCodeUrl(ctx context.Context, state, nonce, codeVerifier string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (Claims, error)
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+const (
+	// jwksTTL is how long the provider keys are trusted before fetching them again.
+	jwksTTL = time.Hour
+	// jwksMinRefresh limits refetching on an unknown key, so bad tokens can't hammer the provider.
+	jwksMinRefresh = time.Minute
+)
+
+type provider struct {
+	config ProviderConfig
+	client *http.Client
+
+	mu        *sync.Mutex
+	discovery *discovery
+
+	keysMu        *sync.Mutex
+	keys          jwk.Set
+	keysFetchedAt time.Time
+}
+
+func NewProvider(config ProviderConfig, client *http.Client) Provider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &provider{
+		config: config,
+		client: client,
+		mu:     &sync.Mutex{},
+		keysMu: &sync.Mutex{},
+	}
+}
+
+func (p *provider) Name() string {
+	return p.config.Name
+}
+
+func (p *provider) AuthCodeUrl(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.config.ClientId)
+	q.Set("redirect_uri", p.config.RedirectUrl)
+	q.Set("scope", strings.Join(p.config.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", CodeChallenge(codeVerifier))
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+func (p *provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (Claims, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectUrl)
+	form.Set("client_id", p.config.ClientId)
+	form.Set("code_verifier", codeVerifier)
+	if p.config.ClientSecret != "" {
+		form.Set("client_secret", p.config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Claims{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tokenResp struct {
+		IdToken string `json:"id_token"`
+	}
+	err = p.doJson(req, &tokenResp)
+	if err != nil {
+		return Claims{}, fmt.Errorf("token exchange failed: %w", err)
+	}
+	if tokenResp.IdToken == "" {
+		return Claims{}, errors.New("token response has no id_token")
+	}
+
+	return p.verify(ctx, d, tokenResp.IdToken, nonce)
+}
+
+func (p *provider) verify(ctx context.Context, d discovery, idToken, nonce string) (Claims, error) {
+	keys, err := p.jwks(ctx, d, false)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	token, err := p.parse(d, keys, idToken, nonce)
+	if err != nil {
+		// the provider may have rotated its keys since they were cached
+		fresh, fetchErr := p.jwks(ctx, d, true)
+		if fetchErr != nil || fresh == keys {
+			return Claims{}, fmt.Errorf("invalid id_token: %w", err)
+		}
+		token, err = p.parse(d, fresh, idToken, nonce)
+		if err != nil {
+			return Claims{}, fmt.Errorf("invalid id_token: %w", err)
+		}
+	}
+
+	claims := Claims{Subject: token.Subject()}
+	if claims.Subject == "" {
+		return Claims{}, errors.New("id_token has no subject")
+	}
+	if email, ok := token.PrivateClaims()["email"].(string); ok {
+		claims.Email = email
+	}
+	if name, ok := token.PrivateClaims()["name"].(string); ok {
+		claims.Name = name
+	}
+	// some providers send the flag as a string
+	switch verified := token.PrivateClaims()["email_verified"].(type) {
+	case bool:
+		claims.EmailVerified = verified
+	case string:
+		claims.EmailVerified = verified == "true"
+	}
+
+	return claims, nil
+}
+
+func (p *provider) parse(d discovery, keys jwk.Set, idToken, nonce string) (jwt.Token, error) {
+	return jwt.Parse(
+		[]byte(idToken),
+		jwt.WithKeySet(keys, jws.WithInferAlgorithmFromKey(true), jws.WithRequireKid(false)),
+		jwt.WithValidate(true),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(p.config.ClientId),
+		jwt.WithClaimValue("nonce", nonce),
+	)
+}
+
+// jwks returns the cached provider keys, fetching them when they are stale. With refresh
+// the keys are fetched again unless that was done less than jwksMinRefresh ago.
+func (p *provider) jwks(ctx context.Context, d discovery, refresh bool) (jwk.Set, error) {
+	p.keysMu.Lock()
+	defer p.keysMu.Unlock()
+
+	age := time.Since(p.keysFetchedAt)
+	if p.keys != nil && age < jwksTTL && (!refresh || age < jwksMinRefresh) {
+		return p.keys, nil
+	}
+
+	keys, err := jwk.Fetch(ctx, d.JwksUri, jwk.WithHTTPClient(p.client))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch provider keys: %w", err)
+	}
+	p.keys, p.keysFetchedAt = keys, time.Now()
+	return keys, nil
+}
+
+func (p *provider) discover(ctx context.Context) (discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return *p.discovery, nil
+	}
+
+	wellKnown := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return discovery{}, err
+	}
+
+	var d discovery
+	err = p.doJson(req, &d)
+	if err != nil {
+		return discovery{}, fmt.Errorf("discovery failed: %w", err)
+	}
+	if d.Issuer != p.config.Issuer {
+		return discovery{}, fmt.Errorf("issuer mismatch: expected %s, got %s", p.config.Issuer, d.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JwksUri == "" {
+		return discovery{}, errors.New("discovery document is incomplete")
+	}
+
+	p.discovery = &d
+	return d, nil
+}
+
+func (p *provider) doJson(req *http.Request, target interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, target)
+}
+
+// CodeChallenge derives the S256 PKCE challenge from the verifier.
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// NewHttpClient returns the client used to talk to providers.
+func NewHttpClient() *http.Client {
+	return &http.Client{Timeout: 10 * time.Second}
+}
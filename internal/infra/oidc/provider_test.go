@@ -0,0 +1,198 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const (
+	testClientId     = "client"
+	testCode         = "code"
+	testCodeVerifier = "verifier"
+	testNonce        = "nonce"
+)
+
+// stubProvider is an identity provider serving discovery, token and JWKS endpoints.
+type stubProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    jwk.Key
+	claims map[string]interface{}
+
+	jwksHits atomic.Int32
+}
+
+func newStubProvider(t *testing.T) *stubProvider {
+	raw, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = key.Set(jwk.KeyIDKey, "test")
+	_ = key.Set(jwk.AlgorithmKey, jwa.RS256)
+
+	s := &stubProvider{t: t, key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/jwks", s.jwks)
+	s.server = httptest.NewServer(mux)
+	t.Cleanup(s.server.Close)
+
+	s.claims = map[string]interface{}{
+		jwt.IssuerKey:     s.server.URL,
+		jwt.AudienceKey:   testClientId,
+		jwt.SubjectKey:    "subject",
+		"nonce":           testNonce,
+		"email":           "user@example.com",
+		"email_verified":  true,
+		"name":            "User",
+		jwt.ExpirationKey: time.Now().Add(time.Minute),
+	}
+	return s
+}
+
+func (s *stubProvider) provider() Provider {
+	return NewProvider(ProviderConfig{
+		Name:        "stub",
+		Issuer:      s.server.URL,
+		ClientId:    testClientId,
+		RedirectUrl: "http://localhost/callback",
+	}, s.server.Client())
+}
+
+func (s *stubProvider) discovery(w http.ResponseWriter, _ *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 s.server.URL,
+		"authorization_endpoint": s.server.URL + "/authorize",
+		"token_endpoint":         s.server.URL + "/token",
+		"jwks_uri":               s.server.URL + "/jwks",
+	})
+}
+
+func (s *stubProvider) token(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil || r.PostForm.Get("code") != testCode || r.PostForm.Get("code_verifier") != testCodeVerifier {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	token := jwt.New()
+	for k, v := range s.claims {
+		_ = token.Set(k, v)
+	}
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.RS256, s.key))
+	if err != nil {
+		s.t.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{"id_token": string(signed)})
+}
+
+func (s *stubProvider) jwks(w http.ResponseWriter, _ *http.Request) {
+	s.jwksHits.Add(1)
+	pub, err := jwk.PublicKeyOf(s.key)
+	if err != nil {
+		s.t.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	set := jwk.NewSet()
+	_ = set.AddKey(pub)
+	_ = json.NewEncoder(w).Encode(set)
+}
+
+func TestExchange(t *testing.T) {
+	s := newStubProvider(t)
+
+	claims, err := s.provider().Exchange(context.Background(), testCode, testCodeVerifier, testNonce)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := Claims{Subject: "subject", Email: "user@example.com", EmailVerified: true, Name: "User"}
+	if claims != want {
+		t.Errorf("got %+v, want %+v", claims, want)
+	}
+}
+
+func TestExchangeRejects(t *testing.T) {
+	tests := []struct {
+		name         string
+		codeVerifier string
+		nonce        string
+		claims       map[string]interface{}
+	}{
+		{name: "wrong code verifier", codeVerifier: "other", nonce: testNonce},
+		{name: "wrong nonce", codeVerifier: testCodeVerifier, nonce: "other"},
+		{name: "wrong audience", codeVerifier: testCodeVerifier, nonce: testNonce,
+			claims: map[string]interface{}{jwt.AudienceKey: "other"}},
+		{name: "wrong issuer", codeVerifier: testCodeVerifier, nonce: testNonce,
+			claims: map[string]interface{}{jwt.IssuerKey: "https://other.example.com"}},
+		{name: "expired", codeVerifier: testCodeVerifier, nonce: testNonce,
+			claims: map[string]interface{}{jwt.ExpirationKey: time.Now().Add(-time.Hour)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStubProvider(t)
+			for k, v := range tt.claims {
+				s.claims[k] = v
+			}
+
+			_, err := s.provider().Exchange(context.Background(), testCode, tt.codeVerifier, tt.nonce)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestExchangeCachesKeys(t *testing.T) {
+	s := newStubProvider(t)
+	p := s.provider()
+
+	for i := 0; i < 3; i++ {
+		_, err := p.Exchange(context.Background(), testCode, testCodeVerifier, testNonce)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if hits := s.jwksHits.Load(); hits != 1 {
+		t.Errorf("keys fetched %d times, want 1", hits)
+	}
+}
+
+func TestAuthCodeUrl(t *testing.T) {
+	s := newStubProvider(t)
+
+	raw, err := s.provider().AuthCodeUrl(context.Background(), "state", testNonce, testCodeVerifier)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("state") != "state" || q.Get("nonce") != testNonce || q.Get("client_id") != testClientId {
+		t.Errorf("unexpected query %s", u.RawQuery)
+	}
+	if q.Get("code_challenge") != CodeChallenge(testCodeVerifier) || q.Get("code_challenge_method") != "S256" {
+		t.Errorf("unexpected PKCE parameters %s", u.RawQuery)
+	}
+}
@@ -0,0 +1,82 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed common.txt
+var commonList string
+
+// common holds the lowercased passwords from common.txt.
+var common = func() map[string]struct{} {
+	set := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(commonList))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			set[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	return set
+}()
+
+// Policy describes what a new password has to look like. Lengths are counted in characters,
+// the classes are lowercase and uppercase letters, digits and everything else.
+type Policy struct {
+	MinLength    int
+	MaxLength    int
+	MinClasses   int
+	RejectCommon bool
+}
+
+func (p Policy) Validate(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters long", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("password must be at most %d characters long", p.MaxLength)
+	}
+	if classes(password) < p.MinClasses {
+		return fmt.Errorf("password must contain at least %d of: lowercase letters, uppercase letters, digits, other characters", p.MinClasses)
+	}
+	if p.RejectCommon && IsCommon(password) {
+		return fmt.Errorf("password is too common")
+	}
+	return nil
+}
+
+// IsCommon reports whether the password is in the bundled list of common passwords.
+func IsCommon(password string) bool {
+	_, found := common[strings.ToLower(password)]
+	return found
+}
+
+func classes(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	count := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
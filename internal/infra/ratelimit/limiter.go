@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter tracks failed attempts per key and locks the key out
+// for an exponentially growing period once the free attempts are spent.
+type Limiter interface {
+	// Check returns the remaining lockout of the key, zero when the key may proceed.
+	Check(key string) time.Duration
+	// Fail registers a failed attempt and returns the lockout it caused.
+	Fail(key string) time.Duration
+	Reset(key string)
+}
+
+type Policy struct {
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	// ForgetAfter is the quiet period after which the failures of a key are dropped.
+	ForgetAfter time.Duration
+}
+
+type attempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+type memoryLimiter struct {
+	policy    Policy
+	now       func() time.Time
+	mu        *sync.Mutex
+	entries   map[string]*attempts
+	lastPrune time.Time
+}
+
+func NewMemoryLimiter(policy Policy) Limiter {
+	return newMemoryLimiter(policy, time.Now)
+}
+
+// newMemoryLimiter takes the clock, so tests can move time instead of sleeping.
+func newMemoryLimiter(policy Policy, now func() time.Time) *memoryLimiter {
+	return &memoryLimiter{
+		policy:    policy,
+		now:       now,
+		mu:        &sync.Mutex{},
+		entries:   make(map[string]*attempts),
+		lastPrune: now(),
+	}
+}
+
+func (l *memoryLimiter) Check(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a, ok := l.entries[key]
+	if !ok {
+		return 0
+	}
+	return l.remaining(a)
+}
+
+func (l *memoryLimiter) Fail(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	a, ok := l.entries[key]
+	if !ok || now.Sub(a.lastFailure) > l.policy.ForgetAfter {
+		a = &attempts{}
+		l.entries[key] = a
+	}
+	a.failures++
+	a.lastFailure = now
+
+	if a.failures > l.policy.FreeAttempts {
+		a.lockedUntil = now.Add(l.delay(a.failures - l.policy.FreeAttempts))
+	}
+	return l.remaining(a)
+}
+
+func (l *memoryLimiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.entries, key)
+}
+
+func (l *memoryLimiter) remaining(a *attempts) time.Duration {
+	left := a.lockedUntil.Sub(l.now())
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// delay doubles the base delay for every failure over the free attempts.
+func (l *memoryLimiter) delay(over int) time.Duration {
+	d := l.policy.BaseDelay
+	for i := 1; i < over; i++ {
+		d *= 2
+		if d >= l.policy.MaxDelay {
+			return l.policy.MaxDelay
+		}
+	}
+	if d > l.policy.MaxDelay {
+		return l.policy.MaxDelay
+	}
+	return d
+}
+
+// prune drops forgotten keys, so the map does not grow with every address seen.
+func (l *memoryLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.policy.ForgetAfter {
+		return
+	}
+	for key, a := range l.entries {
+		if now.Sub(a.lastFailure) > l.policy.ForgetAfter && !now.Before(a.lockedUntil) {
+			delete(l.entries, key)
+		}
+	}
+	l.lastPrune = now
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{
+	FreeAttempts: 2,
+	BaseDelay:    time.Second,
+	MaxDelay:     10 * time.Second,
+	ForgetAfter:  time.Hour,
+}
+
+// clock is a fake time source moved by the tests.
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newTestLimiter() (*memoryLimiter, *clock) {
+	c := &clock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	return newMemoryLimiter(testPolicy, c.now), c
+}
+
+func TestFailBacksOffExponentially(t *testing.T) {
+	l, _ := newTestLimiter()
+
+	want := []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if got := l.Fail("key"); got != w {
+			t.Errorf("failure %d: got lockout %s, want %s", i+1, got, w)
+		}
+	}
+}
+
+func TestLockoutExpires(t *testing.T) {
+	l, c := newTestLimiter()
+
+	for i := 0; i < 4; i++ {
+		l.Fail("key")
+	}
+	if got := l.Check("key"); got != 2*time.Second {
+		t.Fatalf("got lockout %s, want 2s", got)
+	}
+
+	c.advance(time.Second)
+	if got := l.Check("key"); got != time.Second {
+		t.Errorf("got lockout %s, want 1s", got)
+	}
+
+	c.advance(time.Second)
+	if got := l.Check("key"); got != 0 {
+		t.Errorf("got lockout %s after expiry, want none", got)
+	}
+}
+
+func TestFailuresAreForgotten(t *testing.T) {
+	l, c := newTestLimiter()
+
+	for i := 0; i < 3; i++ {
+		l.Fail("key")
+	}
+	c.advance(testPolicy.ForgetAfter + time.Second)
+
+	if got := l.Fail("key"); got != 0 {
+		t.Errorf("got lockout %s after a quiet period, want none", got)
+	}
+}
+
+func TestReset(t *testing.T) {
+	l, _ := newTestLimiter()
+
+	for i := 0; i < 3; i++ {
+		l.Fail("key")
+	}
+	l.Reset("key")
+
+	if got := l.Check("key"); got != 0 {
+		t.Errorf("got lockout %s after reset, want none", got)
+	}
+}
+
+func TestKeysAreIndependent(t *testing.T) {
+	accounts, _ := newTestLimiter()
+	ips, _ := newTestLimiter()
+
+	for i := 0; i < 3; i++ {
+		accounts.Fail("user@example.com")
+		ips.Fail("192.0.2.1")
+	}
+
+	if got := accounts.Check("other@example.com"); got != 0 {
+		t.Errorf("other account locked out for %s", got)
+	}
+	if got := ips.Check("192.0.2.2"); got != 0 {
+		t.Errorf("other address locked out for %s", got)
+	}
+	if got := accounts.Check("192.0.2.1"); got != 0 {
+		t.Errorf("account limiter locked out an address for %s", got)
+	}
+
+	accounts.Reset("user@example.com")
+	if got := ips.Check("192.0.2.1"); got != time.Second {
+		t.Errorf("address lockout %s after the account was reset, want 1s", got)
+	}
+}